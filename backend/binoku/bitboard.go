@@ -0,0 +1,336 @@
+package binoku
+
+import (
+	"context"
+	"math/bits"
+	"math/rand"
+	"slices"
+)
+
+// cancellationCheckInterval is how many cells are tried between checks
+// for whether the context has been cancelled
+const cancellationCheckInterval = 1024
+
+// bitBoard is a board that stores each row and column as bitmasks so
+// that placing a value can be checked against the rules incrementally,
+// without copying the board or re-validating every line
+type bitBoard struct {
+	rows int
+	cols int
+	// rowOnes has bit col set if (row, col) is a 1
+	rowOnes []uint32
+	// rowFilled has bit col set if (row, col) is not empty
+	rowFilled []uint32
+	// colOnes has bit row set if (row, col) is a 1
+	colOnes []uint32
+	// colFilled has bit row set if (row, col) is not empty
+	colFilled []uint32
+	// links are the constraints on each cell, indexed by row*cols+col.
+	// nil if the board has no constraints
+	links [][]constraintLink
+
+	// steps counts cells tried, to know when to check for cancellation
+	steps int
+}
+
+// constraintLink is one end of a constraint between two cells
+type constraintLink struct {
+	// row and col are the cell at the other end of the constraint
+	row   int
+	col   int
+	equal bool
+}
+
+// newEmptyBitBoard creates a rows x cols bitBoard with every cell empty
+func newEmptyBitBoard(rows int, cols int) *bitBoard {
+	return &bitBoard{
+		rows:      rows,
+		cols:      cols,
+		rowOnes:   make([]uint32, rows),
+		rowFilled: make([]uint32, rows),
+		colOnes:   make([]uint32, cols),
+		colFilled: make([]uint32, cols),
+	}
+}
+
+// newBitBoard creates a bitBoard from a board and the constraints
+// between its cells. The board and constraints must already be valid
+func newBitBoard(board [][]GamePiece, constraints []Constraint) *bitBoard {
+	rows, cols := len(board), len(board[0])
+	b := newEmptyBitBoard(rows, cols)
+
+	if len(constraints) > 0 {
+		b.links = make([][]constraintLink, rows*cols)
+		for _, constraint := range constraints {
+			first, second := constraint.Cells[0], constraint.Cells[1]
+			equal := constraint.Type == EqualConstraint
+			b.links[first.Row*cols+first.Col] = append(
+				b.links[first.Row*cols+first.Col],
+				constraintLink{row: second.Row, col: second.Col, equal: equal},
+			)
+			b.links[second.Row*cols+second.Col] = append(
+				b.links[second.Row*cols+second.Col],
+				constraintLink{row: first.Row, col: first.Col, equal: equal},
+			)
+		}
+	}
+
+	for row := range rows {
+		for col := range cols {
+			if board[row][col] != Empty {
+				b.place(row, col, board[row][col])
+			}
+		}
+	}
+
+	return b
+}
+
+// clone copies the board. Constraints never change, so the copy shares
+// the original's links
+func (b *bitBoard) clone() *bitBoard {
+	return &bitBoard{
+		rows:      b.rows,
+		cols:      b.cols,
+		rowOnes:   slices.Clone(b.rowOnes),
+		rowFilled: slices.Clone(b.rowFilled),
+		colOnes:   slices.Clone(b.colOnes),
+		colFilled: slices.Clone(b.colFilled),
+		links:     b.links,
+	}
+}
+
+// emptyCount counts the empty cells
+func (b *bitBoard) emptyCount() int {
+	full := uint32(1)<<b.cols - 1
+	count := 0
+	for _, filled := range b.rowFilled {
+		count += bits.OnesCount32(full &^ filled)
+	}
+
+	return count
+}
+
+// get gets the value at a cell
+func (b *bitBoard) get(row int, col int) GamePiece {
+	bit := uint32(1) << col
+	if b.rowFilled[row]&bit == 0 {
+		return Empty
+	}
+	if b.rowOnes[row]&bit == 0 {
+		return 0
+	}
+
+	return 1
+}
+
+// place puts a value in an empty cell without checking the rules
+func (b *bitBoard) place(row int, col int, value GamePiece) {
+	b.rowFilled[row] |= 1 << col
+	b.colFilled[col] |= 1 << row
+	if value == 1 {
+		b.rowOnes[row] |= 1 << col
+		b.colOnes[col] |= 1 << row
+	}
+}
+
+// clear empties a cell
+func (b *bitBoard) clear(row int, col int) {
+	b.rowFilled[row] &^= 1 << col
+	b.colFilled[col] &^= 1 << row
+	b.rowOnes[row] &^= 1 << col
+	b.colOnes[col] &^= 1 << row
+}
+
+// canPlace returns true if placing value in the empty cell at (row, col)
+// does not break any of the rules
+func (b *bitBoard) canPlace(row int, col int, value GamePiece) bool {
+	return lineAccepts(b.rowOnes, b.rowFilled, row, col, value, b.cols) &&
+		lineAccepts(b.colOnes, b.colFilled, col, row, value, b.rows) &&
+		b.linksAccept(row, col, value)
+}
+
+// linksAccept checks the constraints on a cell when value is placed in
+// it. Constraints to empty cells always accept the value
+func (b *bitBoard) linksAccept(row int, col int, value GamePiece) bool {
+	if b.links == nil {
+		return true
+	}
+
+	for _, link := range b.links[row*b.cols+col] {
+		other := b.get(link.row, link.col)
+		if other != Empty && (other == value) != link.equal {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lineAccepts checks the rules for a single line when value is placed
+// at index. ones and filled hold every line in the same direction, each
+// of which is n cells long
+func lineAccepts(ones []uint32, filled []uint32, line int, index int, value GamePiece, n int) bool {
+	bit := uint32(1) << index
+	lineOnes := ones[line]
+	lineFilled := filled[line] | bit
+	if value == 1 {
+		lineOnes |= bit
+	}
+
+	// 1. There must be an equal number of 1's and 0's in each row/column
+	matching := lineOnes
+	if value == 0 {
+		matching = lineFilled &^ lineOnes
+	}
+	if bits.OnesCount32(matching) > n/2 {
+		return false
+	}
+
+	// 2. There cannot be more than 2 consecutive values next to each other
+	// A triple exists if a bit, the bit after it and the bit after that
+	// are all set
+	if matching&(matching>>1)&(matching>>2) != 0 {
+		return false
+	}
+
+	// 3. There cannot be any identical rows or any identical columns. This
+	// only applies once the line is full
+	full := uint32(1)<<n - 1
+	if lineFilled != full {
+		return true
+	}
+	for other := range filled {
+		if other != line && filled[other] == full && ones[other] == lineOnes {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mostConstrained finds the empty cell with the fewest values that can
+// be placed in it, preferring earlier cells on ties. Returns -1 if the
+// board is full. If the cell has no options, the board can't be solved
+func (b *bitBoard) mostConstrained() (int, []GamePiece) {
+	full := uint32(1)<<b.cols - 1
+	bestCell := -1
+	var bestOptions [2]GamePiece
+	bestCount := 3
+
+	for row := range b.rows {
+		empty := ^b.rowFilled[row] & full
+		for empty != 0 {
+			col := bits.TrailingZeros32(empty)
+			empty &^= 1 << col
+
+			var options [2]GamePiece
+			count := 0
+			for _, value := range []GamePiece{0, 1} {
+				if b.canPlace(row, col, value) {
+					options[count] = value
+					count++
+				}
+			}
+
+			if count < bestCount {
+				bestCell, bestOptions, bestCount = row*b.cols+col, options, count
+			}
+			// Nothing can be more constrained than a cell with no options
+			if count == 0 {
+				return bestCell, nil
+			}
+		}
+	}
+
+	if bestCell < 0 {
+		return -1, nil
+	}
+
+	return bestCell, bestOptions[:bestCount]
+}
+
+// toBoard converts the bitBoard back into a board
+func (b *bitBoard) toBoard() [][]GamePiece {
+	board := make([][]GamePiece, b.rows)
+	for row := range b.rows {
+		board[row] = make([]GamePiece, b.cols)
+		for col := range b.cols {
+			board[row][col] = b.get(row, col)
+		}
+	}
+
+	return board
+}
+
+// checkCancelled returns the context's error if it has been cancelled.
+// The context is only checked every cancellationCheckInterval calls
+func (b *bitBoard) checkCancelled(ctx context.Context) error {
+	b.steps++
+	if b.steps%cancellationCheckInterval != 0 {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// fill fills every empty cell with a random valid value. Returns false
+// if the board cannot be filled
+func (b *bitBoard) fill(ctx context.Context, rng *rand.Rand) (bool, error) {
+	if err := b.checkCancelled(ctx); err != nil {
+		return false, err
+	}
+
+	// Filling the most constrained cell first finds dead ends as early
+	// as possible, instead of after filling the rest of the board
+	cell, options := b.mostConstrained()
+	if cell < 0 {
+		return true, nil
+	}
+	row, col := cell/b.cols, cell%b.cols
+
+	// Randomly pick a 0 or 1 first
+	if len(options) == 2 && rng.Intn(2) == 1 {
+		options[0], options[1] = options[1], options[0]
+	}
+	for _, value := range options {
+		b.place(row, col, value)
+		filled, err := b.fill(ctx, rng)
+		if err != nil || filled {
+			return filled, err
+		}
+		// It didn't work, undo move
+		b.clear(row, col)
+	}
+
+	return false, nil
+}
+
+// findSolutions solves the board, collecting up to limit solutions
+func (b *bitBoard) findSolutions(ctx context.Context, limit int, solutions *[][][]GamePiece) error {
+	if len(*solutions) >= limit {
+		return nil
+	}
+	if err := b.checkCancelled(ctx); err != nil {
+		return err
+	}
+
+	cell, options := b.mostConstrained()
+	// Base case
+	if cell < 0 {
+		*solutions = append(*solutions, b.toBoard())
+		return nil
+	}
+	row, col := cell/b.cols, cell%b.cols
+
+	for _, value := range options {
+		b.place(row, col, value)
+		err := b.findSolutions(ctx, limit, solutions)
+		b.clear(row, col)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
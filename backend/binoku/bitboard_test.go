@@ -0,0 +1,116 @@
+package binoku
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"web_games/services"
+	"web_games/utils"
+)
+
+var benchmarkSizes = []int{4, 6, 8, 10, 12, 14}
+
+// legacyBenchmarkSizes stops at 10, since the legacy solver takes
+// minutes to solve a single larger board
+var legacyBenchmarkSizes = []int{4, 6, 8, 10}
+
+// newTestGameManager creates a GameManager without a puzzle pool, which
+// is stopped when the test finishes
+func newTestGameManager(tb testing.TB) GameManager {
+	key, err := utils.GenerateGCMKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	return NewGameManager(ctx, utils.BinokuConfig{}, services.NewEncryption(key))
+}
+
+// benchmarkPuzzle generates the puzzle each solver benchmark solves
+func benchmarkPuzzle(b *testing.B, size int) [][]GamePiece {
+	game, err := newTestGameManager(b).GenerateBoard(context.Background(), size, size, HardDifficulty, ClassicVariant, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return game.Board
+}
+
+func BenchmarkGenerateBoard(b *testing.B) {
+	gm := newTestGameManager(b)
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := range b.N {
+				_, err := gm.GenerateBoard(context.Background(), size, size, HardDifficulty, ClassicVariant, int64(i))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSolve(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		puzzle := benchmarkPuzzle(b, size)
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for range b.N {
+				var solutions [][][]GamePiece
+				err := newBitBoard(puzzle, nil).findSolutions(context.Background(), 2, &solutions)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(solutions) != 1 {
+					b.Fatalf("expected 1 solution, got %d", len(solutions))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLegacySolve is the solver that copied the board and
+// re-validated it for every candidate, kept as a baseline for BenchmarkSolve
+func BenchmarkLegacySolve(b *testing.B) {
+	for _, size := range legacyBenchmarkSizes {
+		puzzle := benchmarkPuzzle(b, size)
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for range b.N {
+				var solutions [][][]GamePiece
+				legacyFindSolutions(utils.DuplicateMatrix(puzzle), getEmptySpaces(puzzle), 2, &solutions)
+				if len(solutions) != 1 {
+					b.Fatalf("expected 1 solution, got %d", len(solutions))
+				}
+			}
+		})
+	}
+}
+
+func legacyFindSolutions(board [][]GamePiece, emptySpaces []Coordinate, limit int, solutions *[][][]GamePiece) {
+	if len(*solutions) >= limit {
+		return
+	}
+
+	if len(emptySpaces) == 0 {
+		*solutions = append(*solutions, utils.DuplicateMatrix(board))
+		return
+	}
+
+	coord, emptySpaces := emptySpaces[0], emptySpaces[1:]
+
+	for _, value := range []GamePiece{0, 1} {
+		if legacyValueIsValid(board, coord.Row, coord.Col, value) {
+			bCpy := utils.DuplicateMatrix(board)
+			bCpy[coord.Row][coord.Col] = value
+			legacyFindSolutions(bCpy, emptySpaces, limit, solutions)
+		}
+	}
+}
+
+func legacyValueIsValid(toValidate [][]GamePiece, row int, col int, value GamePiece) bool {
+	board := utils.DuplicateMatrix(toValidate)
+	board[row][col] = value
+
+	isValid, _ := boardIsValid(board)
+	return isValid
+}
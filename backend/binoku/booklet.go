@@ -0,0 +1,148 @@
+package binoku
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+const (
+	// MaxBookletPuzzles is the most puzzles that can be put in a booklet
+	// by the server. Larger booklets can be made with the command line
+	MaxBookletPuzzles = 12
+	// bookletPuzzlesPerPage is how many puzzles fit on a page
+	bookletPuzzlesPerPage = 4
+	// bookletSolutionsPerPage is how many solutions fit on a page.
+	// Solutions are drawn smaller than puzzles, so more fit
+	bookletSolutionsPerPage = 12
+	// bookletPuzzleCellSize and bookletSolutionCellSize are the cell
+	// sizes used to render puzzles and solutions before they are scaled
+	// to fit the page
+	bookletPuzzleCellSize   = 40
+	bookletSolutionCellSize = 16
+)
+
+// bookletTemplate lays out each page of a booklet on a sheet of A4
+var bookletTemplate = template.Must(template.New("booklet").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+	@page { size: A4; margin: 15mm; }
+	body { margin: 0; font-family: "Dosis", Helvetica, sans-serif; color: #36382E; }
+	.page { break-after: page; }
+	.page:last-child { break-after: auto; }
+	h1 { font-size: 18pt; margin: 0 0 8mm; }
+	.grid { display: grid; gap: 8mm; }
+	.puzzles { grid-template-columns: repeat(2, 1fr); }
+	.solutions { grid-template-columns: repeat(4, 1fr); }
+	figure { margin: 0; text-align: center; break-inside: avoid; }
+	figure svg { width: 100%; height: auto; }
+	figcaption { font-size: 10pt; }
+</style>
+</head>
+<body>
+{{range .Pages}}<section class="page">
+<h1>{{.Heading}}</h1>
+<div class="grid {{.Class}}">
+{{range .Figures}}<figure>
+{{.Image}}<figcaption>{{.Caption}}</figcaption>
+</figure>
+{{end}}</div>
+</section>
+{{end}}</body>
+</html>
+`))
+
+// bookletPage is a single printed page of a booklet
+type bookletPage struct {
+	Heading string
+	// Class is the class of the page's grid, which sets how many
+	// figures go across the page
+	Class   string
+	Figures []bookletFigure
+}
+
+// bookletFigure is a puzzle or a solution on a page
+type bookletFigure struct {
+	Image   template.HTML
+	Caption string
+}
+
+// RenderBooklet renders games as a printable HTML document, with the
+// puzzles on the first pages and every solution at the back
+func RenderBooklet(w io.Writer, title string, games []Game) error {
+	puzzles := make([]bookletFigure, len(games))
+	solutions := make([]bookletFigure, len(games))
+	for i, game := range games {
+		caption := bookletCaption(i, game)
+
+		image, err := renderSVGString(game.Board, RenderOptions{CellSize: bookletPuzzleCellSize})
+		if err != nil {
+			return err
+		}
+		puzzles[i] = bookletFigure{Image: image, Caption: caption}
+
+		// Marking the givens on the solution shows which cells were solved
+		image, err = renderSVGString(game.solution, RenderOptions{
+			Givens:   game.Board,
+			CellSize: bookletSolutionCellSize,
+		})
+		if err != nil {
+			return err
+		}
+		solutions[i] = bookletFigure{Image: image, Caption: caption}
+	}
+
+	pages := paginate(title, "puzzles", puzzles, bookletPuzzlesPerPage)
+	pages = append(pages, paginate("Solutions", "solutions", solutions, bookletSolutionsPerPage)...)
+
+	return bookletTemplate.Execute(w, struct {
+		Title string
+		Pages []bookletPage
+	}{
+		Title: title,
+		Pages: pages,
+	})
+}
+
+// bookletCaption labels a puzzle with its number, difficulty and ID, so
+// that it can be found again
+func bookletCaption(index int, game Game) string {
+	caption := fmt.Sprintf("#%d · %s", index+1, game.Difficulty)
+	if game.PuzzleID != "" {
+		caption += " · " + game.PuzzleID
+	}
+
+	return caption
+}
+
+// paginate splits figures into pages of at most perPage figures
+func paginate(heading string, class string, figures []bookletFigure, perPage int) []bookletPage {
+	pages := []bookletPage{}
+	for start := 0; start < len(figures); start += perPage {
+		end := min(start+perPage, len(figures))
+		pages = append(pages, bookletPage{
+			Heading: heading,
+			Class:   class,
+			Figures: figures[start:end],
+		})
+	}
+
+	return pages
+}
+
+// renderSVGString renders a board as an SVG that can be put directly
+// into an HTML template
+func renderSVGString(board [][]GamePiece, options RenderOptions) (template.HTML, error) {
+	var svg strings.Builder
+	err := RenderSVG(&svg, board, options)
+	if err != nil {
+		return "", err
+	}
+
+	// The SVG is generated entirely by RenderSVG, so it is safe
+	return template.HTML(svg.String()), nil
+}
@@ -0,0 +1,48 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderBooklet(t *testing.T) {
+	games, err := newTestGameManager(t).GenerateBooklet(context.Background(), 6, 6, EasyDifficulty, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var booklet strings.Builder
+	err = RenderBooklet(&booklet, "Test booklet", games)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := booklet.String()
+	// 2 pages of puzzles, then a page of solutions
+	if got := strings.Count(html, `<section class="page">`); got != 3 {
+		t.Errorf("expected 3 pages, got %d", got)
+	}
+	if got := strings.Count(html, "<svg "); got != 10 {
+		t.Errorf("expected 10 boards, got %d", got)
+	}
+	if strings.Index(html, "Solutions") < strings.LastIndex(html, `class="grid puzzles"`) {
+		t.Error("solutions should be after every puzzle")
+	}
+}
+
+func TestGenerateBookletTimeBudget(t *testing.T) {
+	gm := newTestGameManager(t)
+
+	// The whole booklet shares the time budget of the request, so once its
+	// deadline has passed no more puzzles are generated, however quick they
+	// would be
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	games, err := gm.GenerateBooklet(ctx, 4, 4, EasyDifficulty, MaxBookletPuzzles)
+	if !errors.Is(err, ErrGenerationTimeout) || games != nil {
+		t.Errorf("expected ErrGenerationTimeout, got %d games, %v", len(games), err)
+	}
+}
@@ -0,0 +1,81 @@
+package binoku
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"web_games/utils"
+)
+
+// constraintDensity is how many cells there are for each constraint
+// placed on a new plus board, before unnecessary constraints are removed
+const constraintDensity = 3
+
+// newConstraints places constraints between random pairs of adjacent
+// cells of a solved board, matching the values in the solution
+func newConstraints(rng *rand.Rand, solution [][]GamePiece) []Constraint {
+	rows, cols := len(solution), len(solution[0])
+
+	pairs := [][2]Coordinate{}
+	for row := range rows {
+		for col := range cols {
+			cell := Coordinate{Col: col, Row: row}
+			if col+1 < cols {
+				pairs = append(pairs, [2]Coordinate{cell, {Col: col + 1, Row: row}})
+			}
+			if row+1 < rows {
+				pairs = append(pairs, [2]Coordinate{cell, {Col: col, Row: row + 1}})
+			}
+		}
+	}
+	pairs = utils.ShuffleSliceWithRand(rng, pairs)[:rows*cols/constraintDensity]
+
+	constraints := make([]Constraint, len(pairs))
+	for i, pair := range pairs {
+		constraintType := DifferentConstraint
+		if solution[pair[0].Row][pair[0].Col] == solution[pair[1].Row][pair[1].Col] {
+			constraintType = EqualConstraint
+		}
+		constraints[i] = Constraint{Type: constraintType, Cells: pair}
+	}
+
+	return constraints
+}
+
+// removeConstraints removes every constraint that isn't needed to solve
+// the puzzle without a technique harder than maxTechnique, so that only
+// the constraints that replaced givens are left
+func removeConstraints(
+	ctx context.Context,
+	rng *rand.Rand,
+	board [][]GamePiece,
+	constraints []Constraint,
+	maxTechnique Technique,
+) ([]Constraint, grade, error) {
+	order := utils.ShuffleSliceWithRand(rng, slices.Clone(constraints))
+	for _, constraint := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, grade{}, err
+		}
+
+		index := slices.Index(constraints, constraint)
+		without := slices.Delete(slices.Clone(constraints), index, index+1)
+		if gradeBoard(board, without, maxTechnique).Solved {
+			constraints = without
+		}
+	}
+
+	return constraints, gradeBoard(board, constraints, maxTechnique), nil
+}
+
+// areAdjacent returns true if two cells share an edge
+func areAdjacent(a Coordinate, b Coordinate) bool {
+	if a.Row == b.Row {
+		return a.Col-b.Col == 1 || b.Col-a.Col == 1
+	}
+	if a.Col == b.Col {
+		return a.Row-b.Row == 1 || b.Row-a.Row == 1
+	}
+
+	return false
+}
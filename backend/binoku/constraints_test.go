@@ -0,0 +1,62 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGeneratePlusBoard(t *testing.T) {
+	gm := newTestGameManager(t)
+
+	for seed := range int64(5) {
+		game, err := gm.GenerateBoard(context.Background(), 6, 6, MediumDifficulty, PlusVariant, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The puzzle must only be unique because of its constraints
+		result, err := gm.Solve(context.Background(), game.Board, game.Constraints)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Status != Solved {
+			t.Fatalf("seed %d: expected a unique solution, got %s", seed, result.Status)
+		}
+		if violations := findConstraintViolations(result.Solutions[0], game.Constraints); len(violations) > 0 {
+			t.Errorf("seed %d: solution breaks constraints: %v", seed, violations)
+		}
+
+		rows, cols, difficulty, variant, parsedSeed, err := ParsePuzzleID(game.PuzzleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows != 6 || cols != 6 || difficulty != MediumDifficulty || variant != PlusVariant || parsedSeed != seed {
+			t.Errorf("puzzle ID %s did not round trip", game.PuzzleID)
+		}
+	}
+}
+
+func TestSolveInvalidConstraints(t *testing.T) {
+	gm := newTestGameManager(t)
+	board := [][]GamePiece{
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+	}
+
+	tests := map[string]Constraint{
+		"not adjacent":  {Type: EqualConstraint, Cells: [2]Coordinate{{Col: 0, Row: 0}, {Col: 1, Row: 1}}},
+		"off the board": {Type: EqualConstraint, Cells: [2]Coordinate{{Col: 3, Row: 0}, {Col: 4, Row: 0}}},
+		"unknown type":  {Type: "less", Cells: [2]Coordinate{{Col: 0, Row: 0}, {Col: 1, Row: 0}}},
+	}
+	for name, constraint := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := gm.Solve(context.Background(), board, []Constraint{constraint})
+			if !errors.Is(err, ErrInvalidConstraint) {
+				t.Errorf("expected ErrInvalidConstraint, got %v", err)
+			}
+		})
+	}
+}
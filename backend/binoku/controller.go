@@ -1,87 +1,652 @@
 package binoku
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
 	"slices"
+	"sync"
+	"time"
+	"web_games/entities"
+	"web_games/services"
 	"web_games/utils"
 )
 
 // GameManager represents a game manager
 type GameManager interface {
-	GenerateBoard(int) (Game, error)
-	ValidateBoard(board [][]GamePiece) (bool, InvalidBoardHint)
+	GenerateBoard(
+		ctx context.Context,
+		rows int,
+		cols int,
+		difficulty Difficulty,
+		variant Variant,
+		seed int64,
+	) (Game, error)
+	GenerateRandomBoard(ctx context.Context, rows int, cols int, difficulty Difficulty, variant Variant) (Game, error)
+	ImportPuzzle(ctx context.Context, board [][]GamePiece) (Game, error)
+	GenerateBooklet(ctx context.Context, rows int, cols int, difficulty Difficulty, count int) ([]Game, error)
+	ValidateBoard(board [][]GamePiece, encryptedState string, client string) (bool, []Violation, *GameResult, error)
+	CheckBoard(
+		ctx context.Context,
+		board [][]GamePiece,
+		puzzle [][]GamePiece,
+		constraints []Constraint,
+	) (CheckResult, error)
+	Solve(ctx context.Context, board [][]GamePiece, constraints []Constraint) (SolveResult, error)
+	Hint(board [][]GamePiece, encryptedState string, client string) (Hint, bool, error)
+	StartSession(encryptedState string, client string) (Session, error)
+	MoveSession(id string, coordinate Coordinate, value GamePiece) (Session, error)
+	UndoSession(id string) (Session, error)
+	RedoSession(id string) (Session, error)
+	ReplaySession(id string) (SessionReplay, error)
 }
 
-type gameManager struct{}
+type gameManager struct {
+	pool              *puzzlePool
+	maxGenerationTime time.Duration
+
+	encryption services.Encryption
+	// plays are the records of issued games, by nonce
+	plays entities.AsyncMap[string, *play]
+	// clientPlays is how many records each client has
+	clientPlays map[string]int
+	// playLock guards creating and removing records
+	playLock *sync.Mutex
+	// sessions are the games being played on the server, by session ID
+	sessions entities.AsyncMap[string, *session]
+	// clientSessions is how many sessions each client has
+	clientSessions map[string]int
+	// sessionLock guards starting and removing sessions
+	sessionLock *sync.Mutex
+}
+
+// NewGameManager is the constructor for a GameManager. Its background
+// work, such as filling the puzzle pool, stops when ctx is done
+func NewGameManager(ctx context.Context, config utils.BinokuConfig, encryption services.Encryption) GameManager {
+	gm := &gameManager{
+		maxGenerationTime: config.MaxGenerationTime,
+		encryption:        encryption,
+		plays:             entities.NewAsyncMap(map[string]*play{}),
+		clientPlays:       map[string]int{},
+		playLock:          &sync.Mutex{},
+		sessions:          entities.NewAsyncMap(map[string]*session{}),
+		clientSessions:    map[string]int{},
+		sessionLock:       &sync.Mutex{},
+	}
+	gm.pool = newPuzzlePool(ctx, config, gm.generateRandomBoard)
+	go gm.pruneExpired(ctx)
+
+	return gm
+}
+
+// GenerateBoard generates a rows x cols puzzle. The same dimensions,
+// difficulty, variant and seed will always generate the same puzzle, so
+// the game is untimed
+func (gm gameManager) GenerateBoard(
+	ctx context.Context,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+	seed int64,
+) (Game, error) {
+	game, err := gm.generateBoard(ctx, rows, cols, difficulty, variant, seed)
+	if err != nil {
+		return Game{}, err
+	}
+
+	return gm.issue(game, false)
+}
+
+// GenerateRandomBoard gets a random puzzle, from the pre-generated pool
+// if there is one available. Nobody has seen the puzzle before, so the
+// game is timed
+func (gm gameManager) GenerateRandomBoard(
+	ctx context.Context,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+) (Game, error) {
+	game, ok := gm.pool.take(rows, cols, difficulty, variant)
+	if !ok {
+		var err error
+		game, err = gm.generateRandomBoard(ctx, rows, cols, difficulty, variant)
+		if err != nil {
+			return Game{}, err
+		}
+	}
+
+	return gm.issue(game, true)
+}
+
+// GenerateBooklet generates count random puzzles to be printed. The
+// games are not issued, since they are played on paper. Every puzzle is
+// generated within a single generation time budget, and the pool is left
+// for games that are played online
+func (gm gameManager) GenerateBooklet(
+	ctx context.Context,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	count int,
+) ([]Game, error) {
+	ctx, cancel := gm.withTimeBudget(ctx)
+	defer cancel()
+
+	games := make([]Game, count)
+	for i := range games {
+		game, err := gm.generateRandomBoard(ctx, rows, cols, difficulty, ClassicVariant)
+		if err != nil {
+			return nil, err
+		}
+		games[i] = game
+	}
+
+	return games, nil
+}
+
+// ImportPuzzle turns a puzzle from elsewhere, such as a book, into a
+// game that can be played and validated like a generated one. The
+// puzzle must have exactly one solution. The user already has the
+// puzzle, so the game is untimed
+func (gm gameManager) ImportPuzzle(ctx context.Context, board [][]GamePiece) (Game, error) {
+	result, err := gm.Solve(ctx, board, nil)
+	if err != nil {
+		return Game{}, err
+	}
+	if result.Status != Solved {
+		return Game{}, ErrNoUniqueSolution
+	}
 
-// NewGameManager is the constructor for a GameManager
-func NewGameManager() GameManager {
-	return &gameManager{}
+	grade := gradeBoard(board, nil, LineAnalysisTechnique)
+	return gm.issue(Game{
+		Board:            utils.DuplicateMatrix(board),
+		Difficulty:       gradeDifficulty(grade),
+		Variant:          ClassicVariant,
+		DifficultyScore:  grade.Score,
+		HardestTechnique: grade.HardestTechnique,
+		solution:         result.Solutions[0],
+	}, false)
 }
 
-func (gm gameManager) GenerateBoard(size int) (Game, error) {
-	board := generateGameBoard(size)
+// issue creates the encrypted state for a game as it is given to a
+// user. This is done when the game is issued, rather than generated, so
+// that the timer doesn't include the time a puzzle spent in the pool.
+// Only games that can't be fetched again should be timed
+func (gm gameManager) issue(game Game, timed bool) (Game, error) {
+	// The state is encrypted so that we can check the board the user
+	// submits is the puzzle they were actually given
+	encryptedState, err := gm.encryption.Encrypt(GameState{
+		Givens:          game.Board,
+		Solution:        game.solution,
+		Constraints:     game.Constraints,
+		DifficultyScore: game.DifficultyScore,
+		IssuedAt:        time.Now(),
+		Nonce:           utils.NewUUIDString(),
+		Timed:           timed,
+	})
+	if err != nil {
+		return Game{}, err
+	}
+
+	game.EncryptedState = encryptedState
+	return game, nil
+}
+
+// generateBoard generates a puzzle without issuing it
+func (gm gameManager) generateBoard(
+	ctx context.Context,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+	seed int64,
+) (Game, error) {
+	if !difficulty.IsValid() {
+		return Game{}, fmt.Errorf("unknown difficulty %q", difficulty)
+	}
+	if !variant.IsValid() {
+		return Game{}, fmt.Errorf("unknown variant %q", variant)
+	}
+
+	ctx, cancel := gm.withTimeBudget(ctx)
+	defer cancel()
+
+	rng := rand.New(rand.NewSource(seed))
+	board, solution, constraints, grade, err := generateGameBoard(ctx, rng, rows, cols, difficulty, variant)
+	if err != nil {
+		return Game{}, toTimeoutError(err)
+	}
+
+	return Game{
+		Board:            board,
+		Difficulty:       difficulty,
+		Variant:          variant,
+		Constraints:      constraints,
+		PuzzleID:         NewPuzzleID(rows, cols, difficulty, variant, seed),
+		DifficultyScore:  grade.Score,
+		HardestTechnique: grade.HardestTechnique,
+		solution:         solution,
+	}, nil
+}
 
-	return Game{Board: board}, nil
+// generateRandomBoard generates a random puzzle without issuing it
+func (gm gameManager) generateRandomBoard(
+	ctx context.Context,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+) (Game, error) {
+	return gm.generateBoard(ctx, rows, cols, difficulty, variant, NewSeed())
 }
 
-func (gm gameManager) ValidateBoard(board [][]GamePiece) (bool, InvalidBoardHint) {
+// withTimeBudget limits the context to the maximum generation time
+func (gm gameManager) withTimeBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if gm.maxGenerationTime <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, gm.maxGenerationTime)
+}
+
+// toTimeoutError converts running out of time into ErrGenerationTimeout
+func toTimeoutError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrGenerationTimeout
+	}
+
+	return err
+}
+
+// ValidateBoard validates a completed board against the puzzle that was
+// issued in encryptedState, returning every rule that the board breaks.
+// If the board is solved, the result of the game is also returned. The
+// game is recorded against client if it hasn't been used before
+func (gm gameManager) ValidateBoard(
+	board [][]GamePiece,
+	encryptedState string,
+	client string,
+) (bool, []Violation, *GameResult, error) {
+	err := validateBoardShape(board)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	state, err := gm.decryptState(encryptedState)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if len(state.Givens) != len(board) || len(state.Givens[0]) != len(board[0]) {
+		return false, nil, nil, ErrInvalidBoard
+	}
+	// Games that have expired or already been completed can't be scored
+	err = gm.updatePlay(state, client, func(*play) error { return nil })
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	// The user can't change the values they were given
+	changedGivens := findChangedGivens(board, state.Givens)
+	if len(changedGivens) > 0 {
+		return false, []Violation{{Rule: GivenChangedViolation, Cells: changedGivens, Lines: []Line{}}}, nil, nil
+	}
+
 	// Confirm there are no empty spaces
-	for i, row := range board {
-		if slices.Contains(row, Empty) {
-			return false, InvalidBoardHint{
-				Rows: []int{i},
+	emptySpaces := getEmptySpaces(board)
+	if len(emptySpaces) > 0 {
+		lines := []Line{}
+		for i, row := range board {
+			if slices.Contains(row, Empty) {
+				lines = append(lines, Line{Type: RowLine, Index: i})
+			}
+		}
+
+		return false, []Violation{{Rule: IncompleteViolation, Cells: emptySpaces, Lines: lines}}, nil, nil
+	}
+
+	violations := append(findViolations(board), findConstraintViolations(board, state.Constraints)...)
+	if len(violations) > 0 {
+		return false, violations, nil, nil
+	}
+
+	// Puzzles only have one solution, so a valid board should always be
+	// the solution. Check anyway, rather than trusting that
+	incorrect := []Coordinate{}
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] != state.Solution[row][col] {
+				incorrect = append(incorrect, Coordinate{Col: col, Row: row})
 			}
 		}
 	}
+	if len(incorrect) > 0 {
+		return false, []Violation{{Rule: IncorrectViolation, Cells: incorrect, Lines: []Line{}}}, nil, nil
+	}
 
-	return boardIsValid(board)
+	var result GameResult
+	err = gm.updatePlay(state, client, func(p *play) error {
+		p.completed = true
+		result = newGameResult(state, p.hintsUsed, time.Now())
+		return nil
+	})
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	return true, violations, &result, nil
 }
 
-// Generate a fully valid board
-func generateGameBoard(n int) [][]GamePiece {
-	var board [][]GamePiece
-	// Fill board with Empty spaces
-	for range n {
-		row := make([]GamePiece, n)
-		for i := range row {
-			row[i] = Empty
+// findChangedGivens finds the cells where a board doesn't have the value
+// the puzzle was issued with
+func findChangedGivens(board [][]GamePiece, givens [][]GamePiece) []Coordinate {
+	changedGivens := []Coordinate{}
+	for row := range board {
+		for col := range board[row] {
+			given := givens[row][col]
+			if given != Empty && board[row][col] != given {
+				changedGivens = append(changedGivens, Coordinate{Col: col, Row: row})
+			}
 		}
-		board = append(board, row)
 	}
 
-	backtrackFill(board, 0, 0)
-	return backtrackSolve(board)
+	return changedGivens
 }
 
-func backtrackFill(board [][]GamePiece, row int, col int) bool {
-	n := len(board)
-	if row == n {
-		return true
+// decryptState decrypts a game's encrypted state
+func (gm gameManager) decryptState(encryptedState string) (GameState, error) {
+	var state GameState
+	err := gm.encryption.Decrypt(encryptedState, &state)
+	if err != nil || len(state.Givens) == 0 {
+		return GameState{}, ErrInvalidGameState
+	}
+
+	return state, nil
+}
+
+// CheckBoard checks an in-progress board, reporting only the cells that
+// already break a rule. If the original puzzle is provided, filled cells
+// that don't match the puzzle's solution are also reported
+func (gm gameManager) CheckBoard(
+	ctx context.Context,
+	board [][]GamePiece,
+	puzzle [][]GamePiece,
+	constraints []Constraint,
+) (CheckResult, error) {
+	err := validateBoardShape(board)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	err = validateConstraints(board, constraints)
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	result := CheckResult{
+		Violations: append(findViolations(board), findConstraintViolations(board, constraints)...),
+		Incorrect:  []Coordinate{},
+	}
+	if puzzle == nil {
+		return result, nil
+	}
+
+	if len(puzzle) != len(board) || len(puzzle[0]) != len(board[0]) {
+		return CheckResult{}, ErrInvalidBoard
+	}
+	solved, err := gm.Solve(ctx, puzzle, constraints)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	if solved.Status != Solved {
+		return CheckResult{}, ErrNoUniqueSolution
+	}
+
+	solution := solved.Solutions[0]
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] != Empty && board[row][col] != solution[row][col] {
+				result.Incorrect = append(result.Incorrect, Coordinate{Col: col, Row: row})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Solve finds the solution to a partially filled board. If the board
+// does not have exactly one solution, the result reports either that
+// there is no solution or two example solutions
+func (gm gameManager) Solve(ctx context.Context, board [][]GamePiece, constraints []Constraint) (SolveResult, error) {
+	err := validateBoardShape(board)
+	if err != nil {
+		return SolveResult{}, err
+	}
+	err = validateConstraints(board, constraints)
+	if err != nil {
+		return SolveResult{}, err
+	}
+
+	// Values that already break the rules can't be part of a solution
+	isValid, _ := boardIsValid(board)
+	if !isValid || len(findConstraintViolations(board, constraints)) > 0 {
+		return SolveResult{Status: NoSolution, Solutions: [][][]GamePiece{}}, nil
+	}
+
+	ctx, cancel := gm.withTimeBudget(ctx)
+	defer cancel()
+
+	var solutions [][][]GamePiece
+	err = newBitBoard(board, constraints).findSolutions(ctx, 2, &solutions)
+	if err != nil {
+		return SolveResult{}, toTimeoutError(err)
+	}
+
+	switch len(solutions) {
+	case 0:
+		return SolveResult{Status: NoSolution, Solutions: [][][]GamePiece{}}, nil
+	case 1:
+		return SolveResult{Status: Solved, Solutions: solutions}, nil
+	default:
+		return SolveResult{Status: MultipleSolutions, Solutions: solutions}, nil
+	}
+}
+
+// Hint finds the next cell that can be deduced without guessing. Returns
+// false if no cell can be deduced. Hints count against the game's score,
+// so each hint that is found is recorded against the issued game, which
+// is recorded against client if it hasn't been used before
+func (gm gameManager) Hint(board [][]GamePiece, encryptedState string, client string) (Hint, bool, error) {
+	err := validateBoardShape(board)
+	if err != nil {
+		return Hint{}, false, err
+	}
+
+	state, err := gm.decryptState(encryptedState)
+	if err != nil {
+		return Hint{}, false, err
+	}
+	if len(state.Givens) != len(board) || len(state.Givens[0]) != len(board[0]) {
+		return Hint{}, false, ErrInvalidBoard
+	}
+	if len(findChangedGivens(board, state.Givens)) > 0 {
+		return Hint{}, false, ErrGivensChanged
+	}
+
+	// Deductions from a board that already breaks a rule can't be trusted
+	isValid, _ := boardIsValid(board)
+	if !isValid || len(findConstraintViolations(board, state.Constraints)) > 0 {
+		return Hint{}, false, ErrBoardHasMistakes
+	}
+
+	var hint Hint
+	var found bool
+	err = gm.updatePlay(state, client, func(p *play) error {
+		hint, found = findHint(board, state.Constraints)
+		if found {
+			p.hintsUsed++
+		}
+		return nil
+	})
+	if err != nil {
+		return Hint{}, false, err
+	}
+
+	return hint, found, nil
+}
+
+// validateBoardShape confirms that a board is a rectangle with an even
+// number of rows and columns that only contains valid game pieces
+func validateBoardShape(board [][]GamePiece) error {
+	rows := len(board)
+	if rows == 0 || rows%2 != 0 {
+		return ErrInvalidBoard
+	}
+
+	cols := len(board[0])
+	if cols == 0 || cols%2 != 0 {
+		return ErrInvalidBoard
 	}
 
-	// Randomly pick a 0 or 1
-	options := []GamePiece{0, 1}
-	options = utils.ShuffleSlice(options)
+	for _, row := range board {
+		if len(row) != cols {
+			return ErrInvalidBoard
+		}
+
+		for _, value := range row {
+			if value != Empty && value != 0 && value != 1 {
+				return ErrInvalidBoard
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateConstraints confirms that every constraint is a known type
+// between two adjacent cells of the board
+func validateConstraints(board [][]GamePiece, constraints []Constraint) error {
+	rows, cols := len(board), len(board[0])
+	for _, constraint := range constraints {
+		if constraint.Type != EqualConstraint && constraint.Type != DifferentConstraint {
+			return ErrInvalidConstraint
+		}
+
+		for _, cell := range constraint.Cells {
+			if cell.Row < 0 || cell.Row >= rows || cell.Col < 0 || cell.Col >= cols {
+				return ErrInvalidConstraint
+			}
+		}
 
-	nextCol := col + 1
-	nextRow := row
-	if nextCol >= n {
-		nextCol = 0
-		nextRow++
+		if !areAdjacent(constraint.Cells[0], constraint.Cells[1]) {
+			return ErrInvalidConstraint
+		}
 	}
 
-	for _, possibleValue := range options {
-		if valueIsValid(board, row, col, possibleValue) {
-			board[row][col] = possibleValue
-			if backtrackFill(board, nextRow, nextCol) {
-				return true
+	return nil
+}
+
+// getEmptySpaces gets the coordinates of every empty space on the board
+func getEmptySpaces(board [][]GamePiece) []Coordinate {
+	emptySpaces := []Coordinate{}
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] == Empty {
+				emptySpaces = append(emptySpaces, Coordinate{Col: col, Row: row})
 			}
-			// It didn't work, undo move
-			board[row][col] = Empty
 		}
 	}
 
-	return false
+	return emptySpaces
+}
+
+// maxGenerationAttempts is how many puzzles are made while looking for
+// one that needs the hardest technique its difficulty allows
+const maxGenerationAttempts = 20
+
+// Generate a puzzle that needs the hardest technique its difficulty
+// allows, so that harder difficulties can't be solved with only easier
+// techniques. Some small boards can never need it, in which case the
+// hardest puzzle that was made is used.
+// Returns the puzzle, its solution and the constraints between its cells
+func generateGameBoard(
+	ctx context.Context,
+	rng *rand.Rand,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+) ([][]GamePiece, [][]GamePiece, []Constraint, grade, error) {
+	technique := difficultyTechniques[difficulty]
+
+	var board, solution [][]GamePiece
+	var constraints []Constraint
+	var result grade
+	for range maxGenerationAttempts {
+		attemptBoard, attemptSolution, attemptConstraints, attemptResult, err := generatePuzzle(
+			ctx,
+			rng,
+			rows,
+			cols,
+			technique,
+			variant,
+		)
+		if err != nil {
+			return nil, nil, nil, grade{}, err
+		}
+
+		if board == nil || attemptResult.HardestTechnique.tier() > result.HardestTechnique.tier() {
+			board, solution, constraints, result = attemptBoard, attemptSolution, attemptConstraints, attemptResult
+		}
+		if result.HardestTechnique == technique {
+			break
+		}
+	}
+
+	return board, solution, constraints, result, nil
+}
+
+// Generate a fully valid board, turn it into a puzzle and grade it.
+// Returns the puzzle, its solution and the constraints between its cells
+func generatePuzzle(
+	ctx context.Context,
+	rng *rand.Rand,
+	rows int,
+	cols int,
+	maxTechnique Technique,
+	variant Variant,
+) ([][]GamePiece, [][]GamePiece, []Constraint, grade, error) {
+	bitBoard := newEmptyBitBoard(rows, cols)
+	filled, err := bitBoard.fill(ctx, rng)
+	if err != nil {
+		return nil, nil, nil, grade{}, err
+	}
+	if !filled {
+		return nil, nil, nil, grade{}, ErrImpossibleDimensions
+	}
+	solution := bitBoard.toBoard()
+
+	// Constraints are added before removing values, so that they can
+	// replace some of the givens
+	var constraints []Constraint
+	if variant == PlusVariant {
+		constraints = newConstraints(rng, solution)
+	}
+
+	board, result, err := backtrackSolve(ctx, rng, solution, constraints, maxTechnique)
+	if err != nil {
+		return nil, nil, nil, result, err
+	}
+
+	if variant == PlusVariant {
+		constraints, result, err = removeConstraints(ctx, rng, board, constraints, maxTechnique)
+		if err != nil {
+			return nil, nil, nil, result, err
+		}
+	}
+
+	return board, solution, constraints, result, nil
 }
 
 // boardIsValid validates that a board is correct. Returns the
@@ -111,7 +676,7 @@ func boardIsValid(board [][]GamePiece) (bool, InvalidBoardHint) {
 		}
 	}
 
-	// Transpose matrix 90 degrees and check the columns
+	// Transpose matrix and check the columns
 	transposed := utils.TransposeMatrix(board)
 	for colIndex, col := range transposed {
 		isRuleOneValid := validateRuleOne(col)
@@ -129,7 +694,7 @@ func boardIsValid(board [][]GamePiece) (bool, InvalidBoardHint) {
 		isRuleThreeValid, offendingColIndex := validateRuleThree(colIndex, transposed)
 		if !isRuleThreeValid {
 			return false, InvalidBoardHint{
-				Rows: []int{colIndex, offendingColIndex},
+				Cols: []int{colIndex, offendingColIndex},
 			}
 		}
 	}
@@ -206,30 +771,42 @@ func validateRuleThree(rowIndex int, board [][]GamePiece) (bool, int) {
 	return true, -1
 }
 
-func valueIsValid(toValidate [][]GamePiece, row int, col int, value GamePiece) bool {
-	board := utils.DuplicateMatrix(toValidate)
-	board[row][col] = value
+// 4. In the plus variant, cells with an = between them must be the same
+// and cells with a × between them must be different. Returns true if
+// either cell is empty
+func validateConstraint(board [][]GamePiece, constraint Constraint) bool {
+	first, second := constraint.Cells[0], constraint.Cells[1]
+	a, b := board[first.Row][first.Col], board[second.Row][second.Col]
+	if a == Empty || b == Empty {
+		return true
+	}
 
-	isValid, _ := boardIsValid(board)
-	return isValid
+	return (a == b) == (constraint.Type == EqualConstraint)
 }
 
-func backtrackSolve(board [][]GamePiece) [][]GamePiece {
-	n := len(board)
-	// To convert a board into a puzzle:
-	// Step 1: Remove a number
-	// Step 2: Check if board is still uniquely solvable
+// backtrackSolve removes values from a copy of a filled board until no more
+// values can be removed without needing a technique harder than
+// maxTechnique to solve it. Because every deduction is forced, the
+// puzzle is always uniquely solvable
+func backtrackSolve(
+	ctx context.Context,
+	rng *rand.Rand,
+	board [][]GamePiece,
+	constraints []Constraint,
+	maxTechnique Technique,
+) ([][]GamePiece, grade, error) {
+	rows, cols := len(board), len(board[0])
 
 	// To minimize going down bad routes and for a better user experience,
 	// we will attempt to take an equal amount from each quadrant, so we
 	// will visit the quadrants in a round-robin fashion
 	var topLeft, topRight, bottomLeft, bottomRight []Coordinate
-	for row := range n {
-		for col := range n {
+	for row := range rows {
+		for col := range cols {
 			coord := Coordinate{Col: col, Row: row}
 
-			top := row < n/2
-			left := col < n/2
+			top := row < rows/2
+			left := col < cols/2
 			if top {
 				if left {
 					topLeft = append(topLeft, coord)
@@ -246,13 +823,13 @@ func backtrackSolve(board [][]GamePiece) [][]GamePiece {
 		}
 	}
 	// Shuffle each to make sure we are visiting cells randomly
-	topLeft = utils.ShuffleSlice(topLeft)
-	bottomLeft = utils.ShuffleSlice(bottomLeft)
-	topRight = utils.ShuffleSlice(topRight)
-	bottomRight = utils.ShuffleSlice(bottomRight)
+	topLeft = utils.ShuffleSliceWithRand(rng, topLeft)
+	bottomLeft = utils.ShuffleSliceWithRand(rng, bottomLeft)
+	topRight = utils.ShuffleSliceWithRand(rng, topRight)
+	bottomRight = utils.ShuffleSliceWithRand(rng, bottomRight)
 	// Now merge them into a new copy
 	coords := []Coordinate{}
-	for i := range n * n {
+	for i := range rows * cols {
 		var coord Coordinate
 		if i%4 == 0 && len(topLeft) > 0 {
 			coord = topLeft[0]
@@ -271,99 +848,22 @@ func backtrackSolve(board [][]GamePiece) [][]GamePiece {
 		coords = append(coords, coord)
 	}
 
-	emptySpaces := []Coordinate{}
-
-	// We can start by removing the first space, since there is nothing to solve
-	coord := coords[0]
-	coords = coords[1:]
-	board[coord.Row][coord.Col] = -1
-	emptySpaces = append(emptySpaces, coord)
-
-	batchSize := 3
-	for len(coords) > 6 {
-		// Step 1
-		removedValues, removedCoords := removeCoords(board, coords, batchSize)
-		emptySpaces = append(emptySpaces, removedCoords...)
-
-		coords = coords[batchSize:]
-
-		if !isUniquelySolvable(board, emptySpaces) {
-			// If it is not uniquely solvable, try putting back one number at a time
-			foundUnique := false
-			for i := range batchSize {
-				coord := removedCoords[i]
-				value := removedValues[i]
-
-				board[coord.Row][coord.Col] = value
-				emptySpaces = slices.DeleteFunc(emptySpaces, func(emptyCoord Coordinate) bool {
-					return emptyCoord.Col == coord.Col && emptyCoord.Row == coord.Row
-				})
-
-				if isUniquelySolvable(board, emptySpaces) {
-					foundUnique = true
-					break
-				}
-			}
-
-			if !foundUnique {
-				return board
-			}
+	// Step 1: Remove a number
+	// Step 2: Check if board can still be solved using techniques no
+	// harder than the difficulty allows. If it can't, put it back
+	b := newBitBoard(board, constraints)
+	for _, coord := range coords {
+		if err := ctx.Err(); err != nil {
+			return nil, grade{}, err
 		}
-	}
-
-	return board
-}
-
-func removeCoords(board [][]GamePiece, coords []Coordinate, n int) ([]GamePiece, []Coordinate) {
-	if n > len(coords) {
-		return nil, nil
-	}
-
-	removedValues := []GamePiece{}
-	removedCoords := []Coordinate{}
-
-	for i := range n {
-		coord := coords[i]
-		removedCoords = append(removedCoords, coord)
-
-		value := board[coord.Row][coord.Col]
-		board[coord.Row][coord.Col] = Empty
-		removedValues = append(removedValues, value)
-	}
-
-	return removedValues, removedCoords
-}
 
-func isUniquelySolvable(board [][]GamePiece, emptySpaces []Coordinate) bool {
-	count := 0
-	countSolutions(board, emptySpaces, &count)
-	return count == 1
-}
-
-// Solves a board and returns if it was successful
-func countSolutions(board [][]GamePiece, emptySpaces []Coordinate, count *int) {
-	// Base case
-	if len(emptySpaces) == 0 {
-		*count++
-		return
-	}
+		value := b.get(coord.Row, coord.Col)
+		b.clear(coord.Row, coord.Col)
 
-	if *count > 1 {
-		return
-	}
-
-	coord, emptySpaces := emptySpaces[0], emptySpaces[1:]
-
-	if valueIsValid(board, coord.Row, coord.Col, 0) {
-		bCpy := utils.DuplicateMatrix(board)
-		bCpy[coord.Row][coord.Col] = 0
-		countSolutions(bCpy, emptySpaces, count)
-	}
-	if valueIsValid(board, coord.Row, coord.Col, 1) {
-		bCpy := utils.DuplicateMatrix(board)
-		bCpy[coord.Row][coord.Col] = 1
-		countSolutions(bCpy, emptySpaces, count)
+		if !gradeBitBoard(b, maxTechnique).Solved {
+			b.place(coord.Row, coord.Col, value)
+		}
 	}
 
-	return
+	return b.toBoard(), gradeBitBoard(b, maxTechnique), nil
 }
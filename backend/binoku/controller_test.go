@@ -0,0 +1,125 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"web_games/utils"
+)
+
+func TestCheckBoard(t *testing.T) {
+	gm := newTestGameManager(t)
+	tests := []struct {
+		name  string
+		board [][]GamePiece
+		rules []ViolationRule
+	}{
+		{
+			name: "empty board",
+			board: [][]GamePiece{
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			rules: []ViolationRule{},
+		},
+		{
+			name: "half of one value",
+			board: [][]GamePiece{
+				{1, 1, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			rules: []ViolationRule{},
+		},
+		{
+			name: "more than half of one value",
+			board: [][]GamePiece{
+				{1, Empty, 1, 1},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			rules: []ViolationRule{ImbalanceViolation},
+		},
+		{
+			name: "matching rows with empties",
+			board: [][]GamePiece{
+				{0, 1, Empty, 1},
+				{0, 1, Empty, 1},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			rules: []ViolationRule{},
+		},
+		{
+			name: "duplicate rows",
+			board: [][]GamePiece{
+				{0, 1, 0, 1},
+				{0, 1, 0, 1},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			rules: []ViolationRule{DuplicateViolation},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := gm.CheckBoard(context.Background(), test.board, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rules := []ViolationRule{}
+			for _, violation := range result.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			if !slices.Equal(rules, test.rules) || len(result.Incorrect) != 0 {
+				t.Errorf("expected %v, got %+v", test.rules, result)
+			}
+		})
+	}
+}
+
+func TestCheckBoardAgainstPuzzle(t *testing.T) {
+	gm := newTestGameManager(t)
+	ctx := context.Background()
+	game, err := gm.GenerateBoard(ctx, 6, 6, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only filled cells that don't match the solution are incorrect
+	empties := getEmptySpaces(game.Board)
+	correct, wrong := empties[0], empties[1]
+	board := utils.DuplicateMatrix(game.Board)
+	board[correct.Row][correct.Col] = game.solution[correct.Row][correct.Col]
+	board[wrong.Row][wrong.Col] = 1 - game.solution[wrong.Row][wrong.Col]
+	result, err := gm.CheckBoard(ctx, board, game.Board, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(result.Incorrect, []Coordinate{wrong}) {
+		t.Errorf("expected %v to be incorrect, got %v", wrong, result.Incorrect)
+	}
+
+	_, err = gm.CheckBoard(ctx, board, game.Board[:4], nil)
+	if !errors.Is(err, ErrInvalidBoard) {
+		t.Errorf("expected ErrInvalidBoard for a puzzle of another size, got %v", err)
+	}
+
+	empty := [][]GamePiece{
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+	}
+	_, err = gm.CheckBoard(ctx, empty, empty, nil)
+	if !errors.Is(err, ErrNoUniqueSolution) {
+		t.Errorf("expected ErrNoUniqueSolution for a puzzle with many solutions, got %v", err)
+	}
+}
@@ -1,22 +1,223 @@
 package binoku
 
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrInvalidBoard is returned when a board is not a rectangle with an
+// even number of rows and columns made up of valid game pieces, or
+// doesn't have the dimensions of the puzzle it is played against. The
+// size limits are checked separately, by ValidateDimensions
+var ErrInvalidBoard = errors.New("Invalid board")
+
+// ErrGenerationTimeout is returned when generating or solving a board
+// takes longer than the maximum generation time
+var ErrGenerationTimeout = errors.New("Ran out of time generating board")
+
+// ErrImpossibleDimensions is returned when a board has more rows or
+// columns than there are distinct valid lines to fill them with
+var ErrImpossibleDimensions = errors.New("No valid board has these dimensions")
+
+// ErrNoUniqueSolution is returned when a puzzle that should have exactly
+// one solution has none or several
+var ErrNoUniqueSolution = errors.New("Puzzle does not have a unique solution")
+
+// ErrInvalidGameState is returned when a game's encrypted state can't be
+// decrypted, usually because it has been tampered with
+var ErrInvalidGameState = errors.New("Invalid game state")
+
+// ErrInvalidConstraint is returned when a constraint is not between two
+// adjacent cells on the board, or is not a known type
+var ErrInvalidConstraint = errors.New("Invalid constraint")
+
+// ErrSessionNotFound is returned when a session doesn't exist, or has
+// expired
+var ErrSessionNotFound = errors.New("Session not found")
+
+// ErrInvalidMove is returned when a move is off the board, changes a
+// given or doesn't change the cell
+var ErrInvalidMove = errors.New("Invalid move")
+
+// ErrNothingToUndo is returned when undoing a session with no moves left
+// to undo
+var ErrNothingToUndo = errors.New("Nothing to undo")
+
+// ErrNothingToRedo is returned when redoing a session with no undone
+// moves
+var ErrNothingToRedo = errors.New("Nothing to redo")
+
+// ErrSessionStarted is returned when starting a session for a game that
+// already has one
+var ErrSessionStarted = errors.New("Session already started for this game")
+
+// ErrTooManySessions is returned when there are too many sessions to
+// start another one
+var ErrTooManySessions = errors.New("Too many sessions")
+
+// ErrTooManyClientSessions is returned when a client has too many
+// sessions to start another one
+var ErrTooManyClientSessions = errors.New("You have too many sessions in progress")
+
+// ErrSessionFinished is returned when changing a session that has been
+// solved, or has reached the maximum number of actions
+var ErrSessionFinished = errors.New("Session is finished")
+
+// ErrBoardHasMistakes is returned when a board already breaks a rule
+var ErrBoardHasMistakes = errors.New("Board has mistakes")
+
+// ErrGivensChanged is returned when a board doesn't have the values the
+// puzzle was issued with
+var ErrGivensChanged = errors.New("Board changes the puzzle's givens")
+
+// ErrGameExpired is returned when a game was issued too long ago to
+// still be played
+var ErrGameExpired = errors.New("Game has expired")
+
+// ErrGameCompleted is returned when a game that has already been solved
+// is used again
+var ErrGameCompleted = errors.New("Game has already been completed")
+
+// ErrTooManyGames is returned when too many games are being played to
+// record another one
+var ErrTooManyGames = errors.New("Too many games are being played")
+
+// ErrTooManyClientGames is returned when a client is playing too many
+// games to record another one
+var ErrTooManyClientGames = errors.New("You have too many games in progress")
+
 // GamePiece represents a valid game piece
 type GamePiece int
 
 const (
 	// Empty represents an empty space on the game board
 	Empty GamePiece = -1
-	// EasyDifficulty is the percentage of empty spaces at the start of a game
-	EasyDifficulty = 0.45
-	// MediumDifficulty is the percentage of empty spaces at the start of a game
-	MediumDifficulty = 0.95
-	// HardDifficulty is the percentage of empty spaces at the start of a game
-	HardDifficulty = 0.25
 )
 
+// Difficulty represents how hard a generated puzzle is
+type Difficulty string
+
+const (
+	// EasyDifficulty is the easiest puzzle difficulty
+	EasyDifficulty Difficulty = "easy"
+	// MediumDifficulty is the default puzzle difficulty
+	MediumDifficulty Difficulty = "medium"
+	// HardDifficulty is the hardest puzzle difficulty
+	HardDifficulty Difficulty = "hard"
+	// DailyDifficulty is the difficulty of the daily puzzle
+	DailyDifficulty = MediumDifficulty
+)
+
+// difficultyTechniques is the hardest technique a player needs to solve
+// a puzzle of each difficulty
+var difficultyTechniques = map[Difficulty]Technique{
+	EasyDifficulty:   SandwichTechnique,
+	MediumDifficulty: BalanceTechnique,
+	HardDifficulty:   LineAnalysisTechnique,
+}
+
+// IsValid returns true if the difficulty is a known difficulty
+func (d Difficulty) IsValid() bool {
+	_, ok := difficultyTechniques[d]
+	return ok
+}
+
+// Variant is a set of rules a puzzle is played with
+type Variant string
+
+const (
+	// ClassicVariant is the standard game, with only the three rules
+	ClassicVariant Variant = "classic"
+	// PlusVariant adds constraints between some adjacent cells, which
+	// replace some of the givens
+	PlusVariant Variant = "plus"
+)
+
+// variants is every variant
+var variants = []Variant{ClassicVariant, PlusVariant}
+
+// IsValid returns true if the variant is a known variant
+func (v Variant) IsValid() bool {
+	return slices.Contains(variants, v)
+}
+
+// ConstraintType is the relationship between the cells of a constraint
+type ConstraintType string
+
+const (
+	// EqualConstraint (=) - both cells have the same value
+	EqualConstraint ConstraintType = "equal"
+	// DifferentConstraint (×) - the cells have different values
+	DifferentConstraint ConstraintType = "different"
+)
+
+// Constraint is a marker between two adjacent cells
+type Constraint struct {
+	Type  ConstraintType `json:"type"`
+	Cells [2]Coordinate  `json:"cells"`
+}
+
 // Game represents a game object
 type Game struct {
-	Board [][]GamePiece `json:"board"`
+	Board      [][]GamePiece `json:"board"`
+	Difficulty Difficulty    `json:"difficulty"`
+	Variant    Variant       `json:"variant"`
+	// Constraints are the markers between cells of a PlusVariant game
+	Constraints []Constraint `json:"constraints,omitempty"`
+	// PuzzleID is a shareable ID that regenerates this exact puzzle
+	PuzzleID string `json:"puzzleId"`
+	// DifficultyScore is how hard the puzzle is to solve by hand. Harder
+	// techniques add more to the score
+	DifficultyScore int `json:"difficultyScore"`
+	// HardestTechnique is the hardest technique needed to solve the puzzle
+	HardestTechnique Technique `json:"hardestTechnique"`
+	// EncryptedState is the encrypted GameState, which must be sent back
+	// to validate the game
+	EncryptedState string `json:"encryptedState"`
+
+	// solution is kept until the game is issued, when it is encrypted
+	solution [][]GamePiece
+}
+
+// GameState is the state of a game that the user must not be able to
+// change or see, so it only leaves the server encrypted
+type GameState struct {
+	// Givens is the puzzle as it was issued
+	Givens [][]GamePiece `json:"givens"`
+	// Solution is the puzzle's only solution
+	Solution [][]GamePiece `json:"solution"`
+	// Constraints are the markers between cells the puzzle was issued with
+	Constraints []Constraint `json:"constraints,omitempty"`
+	// DifficultyScore is how hard the puzzle is to solve
+	DifficultyScore int `json:"difficultyScore"`
+	// IssuedAt is when the game was given to the user
+	IssuedAt time.Time `json:"issuedAt"`
+	// Nonce identifies this issue of the game. The hints used and whether
+	// it has been completed are recorded on the server by nonce
+	Nonce string `json:"nonce"`
+	// Timed is true if the puzzle couldn't have been seen before it was
+	// issued, so that how long it took to solve can be scored
+	Timed bool `json:"timed"`
+}
+
+// GameResult is the result of a solved game. It is not proof of how the
+// game was played: any board can be checked or solved without being tied
+// to an issued game, so the results are only fair between players who
+// don't do that
+type GameResult struct {
+	// ElapsedSeconds is how long the game took to solve
+	ElapsedSeconds int `json:"elapsedSeconds"`
+	// HintsUsed is how many hints the user was given for the game. Checking
+	// or solving the board doesn't count
+	HintsUsed int `json:"hintsUsed"`
+	// Score rewards solving harder and larger puzzles quickly and
+	// without hints. Untimed games are not scored
+	Score int `json:"score"`
+	// Timed is false if the puzzle can be fetched again, such as by its
+	// ID or by importing it, since it could have been solved before it
+	// was issued
+	Timed bool `json:"timed"`
 }
 
 // Coordinate represents a space on the board
@@ -34,10 +235,244 @@ type InvalidBoardHint struct {
 // ValidateGameRequest is the requests to validate a completed board
 type ValidateGameRequest struct {
 	Board [][]GamePiece `json:"board"`
+	// EncryptedState is the encrypted state of the game being validated
+	EncryptedState string `json:"encryptedState"`
 }
 
 // ValidateGameResponse is the resonse to validate a completed board
 type ValidateGameResponse struct {
 	Valid bool             `json:"valid"`
 	Hint  InvalidBoardHint `json:"hint,omitempty"`
+	// Violations is every rule the board breaks
+	Violations []Violation `json:"violations"`
+	// Result is only set when the board is solved
+	Result *GameResult `json:"result,omitempty"`
+}
+
+// CheckBoardRequest is the request to check an in-progress board
+type CheckBoardRequest struct {
+	Board [][]GamePiece `json:"board"`
+	// Puzzle is the original puzzle. If provided, cells are also checked
+	// against the puzzle's solution
+	Puzzle [][]GamePiece `json:"puzzle,omitempty"`
+	// Constraints are the markers between cells of a PlusVariant board
+	Constraints []Constraint `json:"constraints,omitempty"`
+}
+
+// CheckResult is the result of checking an in-progress board
+type CheckResult struct {
+	// Violations is every rule the filled cells already break
+	Violations []Violation `json:"violations"`
+	// Incorrect is every filled cell that does not match the solution.
+	// Only set when checking against the puzzle
+	Incorrect []Coordinate `json:"incorrect"`
+}
+
+// ViolationRule is the rule broken by a violation
+type ViolationRule string
+
+const (
+	// IncompleteViolation - the board still has empty spaces
+	IncompleteViolation ViolationRule = "incomplete"
+	// ImbalanceViolation - a line has more of one value than the other
+	ImbalanceViolation ViolationRule = "imbalance"
+	// TripleViolation - a line has more than 2 of the same value in a row
+	TripleViolation ViolationRule = "triple"
+	// DuplicateViolation - two rows or two columns are identical
+	DuplicateViolation ViolationRule = "duplicate"
+	// GivenChangedViolation - a value the puzzle started with was changed
+	GivenChangedViolation ViolationRule = "given-changed"
+	// IncorrectViolation - the board does not match the puzzle's solution
+	IncorrectViolation ViolationRule = "incorrect"
+	// ConstraintViolation - two cells break the constraint between them
+	ConstraintViolation ViolationRule = "constraint"
+)
+
+// LineType is whether a line is a row or a column
+type LineType string
+
+const (
+	// RowLine is a row of the board
+	RowLine LineType = "row"
+	// ColumnLine is a column of the board
+	ColumnLine LineType = "col"
+)
+
+// Line is a single row or column of the board
+type Line struct {
+	Type  LineType `json:"type"`
+	Index int      `json:"index"`
+}
+
+// Violation is a single broken rule on the board
+type Violation struct {
+	Rule ViolationRule `json:"rule"`
+	// Cells are the cells that break the rule
+	Cells []Coordinate `json:"cells"`
+	// Lines is the line breaking the rule, or both lines for duplicates
+	Lines []Line `json:"lines"`
+}
+
+// SolveStatus is the outcome of solving a board
+type SolveStatus string
+
+const (
+	// Solved means the board has exactly one solution
+	Solved SolveStatus = "solved"
+	// NoSolution means the board cannot be solved
+	NoSolution SolveStatus = "no-solution"
+	// MultipleSolutions means the board has more than one solution
+	MultipleSolutions SolveStatus = "multiple-solutions"
+)
+
+// SolveRequest is the request to solve a partially filled board
+type SolveRequest struct {
+	Board [][]GamePiece `json:"board"`
+	// Constraints are the markers between cells of a PlusVariant board
+	Constraints []Constraint `json:"constraints,omitempty"`
+}
+
+// SolveResult is the result of solving a board. Solutions contains the
+// unique solution, or two distinct solutions if there are multiple
+type SolveResult struct {
+	Status    SolveStatus     `json:"status"`
+	Solutions [][][]GamePiece `json:"solutions"`
+}
+
+// Technique is a logical technique that forces the value of a cell
+type Technique string
+
+const (
+	// ConstraintTechnique - a cell next to a filled cell, with a
+	// constraint between them, must be the same or the opposite value
+	ConstraintTechnique Technique = "constraint"
+	// PairTechnique - two equal values next to each other force the
+	// cells on either side to be the opposite value
+	PairTechnique Technique = "pair"
+	// SandwichTechnique - a cell between two equal values must be the
+	// opposite value
+	SandwichTechnique Technique = "sandwich"
+	// BalanceTechnique - once a line has half of one value, the rest of
+	// the line must be the opposite value
+	BalanceTechnique Technique = "balance"
+	// UniquenessTechnique - a line cannot be completed into a copy of
+	// another completed line
+	UniquenessTechnique Technique = "uniqueness"
+	// LineAnalysisTechnique - a value that makes every way of completing
+	// its line break a rule can't be placed
+	LineAnalysisTechnique Technique = "line-analysis"
+)
+
+// Hint is a single cell that can be logically deduced
+type Hint struct {
+	Coordinate Coordinate `json:"coordinate"`
+	Value      GamePiece  `json:"value"`
+	Rule       Technique  `json:"rule"`
+}
+
+// HintRequest is the request for the next logical step of a board
+type HintRequest struct {
+	Board [][]GamePiece `json:"board"`
+	// EncryptedState is the encrypted state of the game being played
+	EncryptedState string `json:"encryptedState"`
+}
+
+// HintResponse is the response to a HintRequest. Found is false when
+// no cell can be deduced without guessing
+type HintResponse struct {
+	Found bool `json:"found"`
+	Hint  Hint `json:"hint,omitempty"`
+}
+
+// RenderFormat is the image format a board is rendered to
+type RenderFormat string
+
+const (
+	// SVGFormat renders the board as an SVG
+	SVGFormat RenderFormat = "svg"
+	// PNGFormat renders the board as a PNG
+	PNGFormat RenderFormat = "png"
+)
+
+// RenderRequest is the request to render a board as an image
+type RenderRequest struct {
+	Board [][]GamePiece `json:"board"`
+	// Givens is the puzzle the board was started from, so the givens can
+	// be told apart from the player's entries. If it is not set, every
+	// filled cell is a given
+	Givens [][]GamePiece `json:"givens,omitempty"`
+	// Hint is the rows and columns to highlight
+	Hint InvalidBoardHint `json:"hint"`
+	// Format defaults to SVGFormat
+	Format RenderFormat `json:"format,omitempty"`
+	// CellSize is the size of each cell in pixels
+	CellSize int `json:"cellSize,omitempty"`
+}
+
+// SessionActionType is the kind of change made to a session's board
+type SessionActionType string
+
+const (
+	// MoveAction is a new value placed in a cell
+	MoveAction SessionActionType = "move"
+	// UndoAction reverts the last move that is still applied
+	UndoAction SessionActionType = "undo"
+	// RedoAction reapplies the last move that was undone
+	RedoAction SessionActionType = "redo"
+)
+
+// SessionAction is a single change made to a session's board
+type SessionAction struct {
+	Type       SessionActionType `json:"type"`
+	Coordinate Coordinate        `json:"coordinate"`
+	// Value is the cell's value after the action
+	Value GamePiece `json:"value"`
+	// Previous is the cell's value before the action
+	Previous GamePiece `json:"previous"`
+	// ElapsedMs is how long after the session started the action was made
+	ElapsedMs int64 `json:"elapsedMs"`
+}
+
+// Session is a game where every move is made on the server
+type Session struct {
+	ID    string        `json:"id"`
+	Board [][]GamePiece `json:"board"`
+	// Constraints are the markers between cells of a PlusVariant game
+	Constraints []Constraint `json:"constraints,omitempty"`
+	CanUndo     bool         `json:"canUndo"`
+	CanRedo     bool         `json:"canRedo"`
+	// Actions is how many actions have been made, including undos and
+	// redos
+	Actions int  `json:"actions"`
+	Solved  bool `json:"solved"`
+}
+
+// SessionReplay is everything needed to replay a session, such as for a
+// time-lapse of the solve
+type SessionReplay struct {
+	ID string `json:"id"`
+	// Givens is the puzzle the session started from
+	Givens      [][]GamePiece `json:"givens"`
+	Constraints []Constraint  `json:"constraints,omitempty"`
+	StartedAt   time.Time     `json:"startedAt"`
+	// Actions is every action in the order they were made
+	Actions []SessionAction `json:"actions"`
+	// Board is the board after every action
+	Board  [][]GamePiece `json:"board"`
+	Solved bool          `json:"solved"`
+	// SolvedAfterMs is how long after the session started it was solved
+	SolvedAfterMs int64 `json:"solvedAfterMs,omitempty"`
+}
+
+// StartSessionRequest is the request to play an issued game as a session
+type StartSessionRequest struct {
+	// EncryptedState is the encrypted state of the game to play
+	EncryptedState string `json:"encryptedState"`
+}
+
+// MoveRequest is the request to place a value in a cell of a session.
+// Empty clears the cell
+type MoveRequest struct {
+	Coordinate Coordinate `json:"coordinate"`
+	Value      GamePiece  `json:"value"`
 }
@@ -0,0 +1,83 @@
+package binoku
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBoardFormat is returned when a board's text can't be parsed
+var ErrInvalidBoardFormat = errors.New("Invalid board format")
+
+// The characters used for each game piece in the text format
+const (
+	zeroChar  = '0'
+	oneChar   = '1'
+	emptyChar = '.'
+	// commentChar starts a line that is ignored, such as the puzzle's
+	// source
+	commentChar = '#'
+)
+
+// ParseBoard parses a board from the text format, which has a line for
+// each row with a 0, 1 or . (empty) for each cell. Whitespace within and
+// around rows, blank lines and lines starting with # are ignored. The
+// board is not checked against the rules
+func ParseBoard(text string) ([][]GamePiece, error) {
+	board := [][]GamePiece{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == commentChar {
+			continue
+		}
+
+		row := []GamePiece{}
+		for _, char := range line {
+			switch char {
+			case zeroChar:
+				row = append(row, 0)
+			case oneChar:
+				row = append(row, 1)
+			case emptyChar:
+				row = append(row, Empty)
+			case ' ', '\t':
+			default:
+				return nil, fmt.Errorf("%w: unexpected %q on line %d", ErrInvalidBoardFormat, char, i+1)
+			}
+		}
+
+		if len(board) > 0 && len(row) != len(board[0]) {
+			return nil, fmt.Errorf(
+				"%w: line %d has %d cells, expected %d",
+				ErrInvalidBoardFormat, i+1, len(row), len(board[0]),
+			)
+		}
+		board = append(board, row)
+	}
+
+	if len(board) == 0 {
+		return nil, fmt.Errorf("%w: board is empty", ErrInvalidBoardFormat)
+	}
+
+	return board, nil
+}
+
+// FormatBoard writes a board in the text format, with a trailing newline
+func FormatBoard(board [][]GamePiece) string {
+	var builder strings.Builder
+	for _, row := range board {
+		for _, piece := range row {
+			switch piece {
+			case 0:
+				builder.WriteRune(zeroChar)
+			case 1:
+				builder.WriteRune(oneChar)
+			default:
+				builder.WriteRune(emptyChar)
+			}
+		}
+		builder.WriteRune('\n')
+	}
+
+	return builder.String()
+}
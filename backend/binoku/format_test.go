@@ -0,0 +1,121 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files with the current output
+var update = flag.Bool("update", false, "update golden files")
+
+// TestSolveGolden solves every puzzle in testdata/puzzles and compares
+// the result with the puzzle's .golden file
+func TestSolveGolden(t *testing.T) {
+	gm := newTestGameManager(t)
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "puzzles", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no puzzles found")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			board, err := ParseBoard(string(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := gm.Solve(context.Background(), board, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, solution := range result.Solutions {
+				if violations := findViolations(solution); len(violations) > 0 {
+					t.Errorf("solution breaks the rules: %v", violations)
+				}
+			}
+
+			got := formatSolveResult(result)
+			goldenPath := strings.TrimSuffix(path, ".txt") + ".golden"
+			if *update {
+				err = os.WriteFile(goldenPath, []byte(got), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("solve result does not match %s\ngot:\n%s\nwant:\n%s", goldenPath, got, want)
+			}
+		})
+	}
+}
+
+// formatSolveResult writes a solve result in the text format, with the
+// status as a comment
+func formatSolveResult(result SolveResult) string {
+	var builder strings.Builder
+	builder.WriteString("# " + string(result.Status) + "\n")
+	for _, solution := range result.Solutions {
+		builder.WriteString("\n" + FormatBoard(solution))
+	}
+
+	return builder.String()
+}
+
+func TestFormatBoardRoundTrip(t *testing.T) {
+	board := [][]GamePiece{
+		{0, 1, Empty, 1},
+		{Empty, Empty, 0, 0},
+		{1, 0, 1, Empty},
+		{Empty, 1, Empty, Empty},
+	}
+	text := FormatBoard(board)
+	if text != "01.1\n..00\n101.\n.1..\n" {
+		t.Fatalf("unexpected text:\n%s", text)
+	}
+
+	parsed, err := ParseBoard(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if FormatBoard(parsed) != text {
+		t.Errorf("round trip changed the board:\n%s", FormatBoard(parsed))
+	}
+}
+
+func TestParseBoardErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty":           "",
+		"only comments":   "# nothing here\n",
+		"unknown piece":   "01x1\n",
+		"different sizes": "0101\n010\n",
+	}
+
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseBoard(text)
+			if !errors.Is(err, ErrInvalidBoardFormat) {
+				t.Errorf("expected ErrInvalidBoardFormat, got %v", err)
+			}
+		})
+	}
+}
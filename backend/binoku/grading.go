@@ -0,0 +1,84 @@
+package binoku
+
+// techniqueScores is how much each deduction using a technique adds to
+// a puzzle's difficulty score
+var techniqueScores = map[Technique]int{
+	ConstraintTechnique:   1,
+	PairTechnique:         1,
+	SandwichTechnique:     1,
+	BalanceTechnique:      2,
+	UniquenessTechnique:   4,
+	LineAnalysisTechnique: 6,
+}
+
+// grade is the result of solving a puzzle using only human techniques
+type grade struct {
+	// Solved is true if the puzzle could be solved without guessing
+	Solved bool
+	// Score is the sum of every deduction's technique score
+	Score int
+	// HardestTechnique is the hardest technique that was required, which
+	// is empty until a deduction has been made
+	HardestTechnique Technique
+}
+
+// gradeDifficulty gets the easiest difficulty that a graded puzzle could
+// have been generated at. Puzzles that need guessing are hard
+func gradeDifficulty(result grade) Difficulty {
+	if !result.Solved {
+		return HardDifficulty
+	}
+
+	for _, difficulty := range []Difficulty{EasyDifficulty, MediumDifficulty} {
+		if result.HardestTechnique.tier() <= difficultyTechniques[difficulty].tier() {
+			return difficulty
+		}
+	}
+
+	return HardDifficulty
+}
+
+// gradeBoard solves a puzzle like a person would, only ever using the
+// easiest technique that makes progress. Techniques harder than
+// maxTechnique are not used
+func gradeBoard(board [][]GamePiece, constraints []Constraint, maxTechnique Technique) grade {
+	return gradeBitBoard(newBitBoard(board, constraints), maxTechnique)
+}
+
+// gradeBitBoard grades a puzzle that is already a bitBoard, without
+// changing it
+func gradeBitBoard(b *bitBoard, maxTechnique Technique) grade {
+	b = b.clone()
+	result := grade{}
+
+	emptySpaces := b.emptyCount()
+	for emptySpaces > 0 {
+		progressed := false
+		for _, technique := range techniques[:maxTechnique.tier()+1] {
+			hints := b.deductions(technique, 0)
+			if len(hints) == 0 {
+				continue
+			}
+
+			for _, hint := range hints {
+				b.place(hint.Coordinate.Row, hint.Coordinate.Col, hint.Value)
+				result.Score += techniqueScores[technique]
+			}
+			emptySpaces -= len(hints)
+			if technique.tier() > result.HardestTechnique.tier() {
+				result.HardestTechnique = technique
+			}
+
+			// Always go back to the easiest technique after progress
+			progressed = true
+			break
+		}
+
+		if !progressed {
+			return result
+		}
+	}
+
+	result.Solved = true
+	return result
+}
@@ -0,0 +1,58 @@
+package binoku
+
+import (
+	"context"
+	"testing"
+	"web_games/utils"
+)
+
+// gradingSolution is a solved 4x4 board
+var gradingSolution = [][]GamePiece{
+	{0, 0, 1, 1},
+	{1, 1, 0, 0},
+	{0, 1, 0, 1},
+	{1, 0, 1, 0},
+}
+
+func TestGradeBoard(t *testing.T) {
+	result := gradeBoard(gradingSolution, nil, LineAnalysisTechnique)
+	if !result.Solved || result.Score != 0 || result.HardestTechnique != "" {
+		t.Errorf("expected a solved board to need nothing, got %+v", result)
+	}
+
+	// The constraint is the easiest technique, so it is all that is needed
+	board := utils.DuplicateMatrix(gradingSolution)
+	board[0][0] = Empty
+	constraints := []Constraint{{Type: EqualConstraint, Cells: [2]Coordinate{{Row: 0, Col: 0}, {Row: 0, Col: 1}}}}
+	result = gradeBoard(board, constraints, LineAnalysisTechnique)
+	if !result.Solved || result.HardestTechnique != ConstraintTechnique {
+		t.Errorf("expected only the constraint to be needed, got %+v", result)
+	}
+}
+
+func TestGenerateDifficulty(t *testing.T) {
+	gm := newTestGameManager(t)
+
+	// Every difficulty needs its own hardest technique, so hard puzzles
+	// can't be solved without line analysis
+	for _, size := range []int{6, 8} {
+		for difficulty, technique := range difficultyTechniques {
+			for _, variant := range variants {
+				for seed := range int64(5) {
+					game, err := gm.GenerateBoard(context.Background(), size, size, difficulty, variant, seed)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if game.HardestTechnique != technique {
+						t.Errorf("%s %s %dx%d seed %d: expected %s, got %s", difficulty, variant, size, size, seed, technique, game.HardestTechnique)
+					}
+					result := gradeBoard(game.Board, game.Constraints, techniques[technique.tier()-1])
+					if result.Solved {
+						t.Errorf("%s %s %dx%d seed %d: solved without %s", difficulty, variant, size, size, seed, technique)
+					}
+				}
+			}
+		}
+	}
+}
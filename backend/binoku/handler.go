@@ -1,15 +1,38 @@
 package binoku
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
+	"web_games/utils"
 )
 
+// maxImportBytes is the largest puzzle that can be imported, which is
+// plenty for the largest board with comments
+const maxImportBytes = 4096
+
 // Handler represents a Binoku handler
 type Handler interface {
 	NewGame(w http.ResponseWriter, r *http.Request)
+	Puzzle(w http.ResponseWriter, r *http.Request)
+	Daily(w http.ResponseWriter, r *http.Request)
 	ValidateBoard(w http.ResponseWriter, r *http.Request)
+	CheckBoard(w http.ResponseWriter, r *http.Request)
+	Solve(w http.ResponseWriter, r *http.Request)
+	Hint(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	ImportSolve(w http.ResponseWriter, r *http.Request)
+	Render(w http.ResponseWriter, r *http.Request)
+	Booklet(w http.ResponseWriter, r *http.Request)
+	StartSession(w http.ResponseWriter, r *http.Request)
+	SessionMove(w http.ResponseWriter, r *http.Request)
+	SessionUndo(w http.ResponseWriter, r *http.Request)
+	SessionRedo(w http.ResponseWriter, r *http.Request)
+	SessionReplay(w http.ResponseWriter, r *http.Request)
 }
 
 type handler struct {
@@ -25,33 +48,167 @@ func NewHandler(controller GameManager) Handler {
 
 // NewGame handles a new game request
 func (h handler) NewGame(w http.ResponseWriter, r *http.Request) {
+	rows, cols, ok := h.getDimensions(w, r)
+	if !ok {
+		return
+	}
+
+	difficulty, ok := h.getDifficulty(w, r)
+	if !ok {
+		return
+	}
+
+	variant := Variant(r.URL.Query().Get("variant"))
+	if variant == "" {
+		variant = ClassicVariant
+	}
+	if !variant.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Variant must be classic or plus"))
+		return
+	}
+
+	board, err := h.controller.GenerateRandomBoard(r.Context(), rows, cols, difficulty, variant)
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	h.writeGame(w, board)
+}
+
+// Puzzle regenerates a previously generated puzzle from its puzzle ID.
+// The puzzle is exported in the text format if format=text
+func (h handler) Puzzle(w http.ResponseWriter, r *http.Request) {
+	rows, cols, difficulty, variant, seed, err := ParsePuzzleID(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	isSizeValid, validationMessage := ValidateDimensions(rows, cols)
+	if !isSizeValid {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(validationMessage))
+		return
+	}
+
+	if r.URL.Query().Get("format") != "text" {
+		h.generateBoard(w, r, rows, cols, difficulty, variant, seed)
+		return
+	}
+
+	// The text format has no way of writing constraints
+	if variant != ClassicVariant {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Only classic puzzles can be exported as text"))
+		return
+	}
+
+	game, err := h.controller.GenerateBoard(r.Context(), rows, cols, difficulty, variant, seed)
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(FormatBoard(game.Board)))
+}
+
+// Daily returns today's puzzle, which is the same for everyone on
+// the same UTC date. The daily puzzle is untimed, so it is not scored:
+// fetching it again restarts the timer, and it can be solved by someone
+// else first. Players can still compare the elapsed time of their
+// results, but those times are not proof of how long a solve took
+func (h handler) Daily(w http.ResponseWriter, r *http.Request) {
+	rows, cols, ok := h.getDimensions(w, r)
+	if !ok {
+		return
+	}
+
+	h.generateBoard(w, r, rows, cols, DailyDifficulty, ClassicVariant, DailySeed(time.Now()))
+}
+
+// getDifficulty reads the difficulty from the request, which defaults to
+// medium. If the difficulty is not valid, the error is written and false
+// is returned
+func (h handler) getDifficulty(w http.ResponseWriter, r *http.Request) (Difficulty, bool) {
+	difficulty := Difficulty(r.URL.Query().Get("difficulty"))
+	if difficulty == "" {
+		difficulty = MediumDifficulty
+	}
+	if !difficulty.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Difficulty must be easy, medium or hard"))
+		return "", false
+	}
+
+	return difficulty, true
+}
+
+// getDimensions reads and validates the board dimensions from the
+// request. Square boards can be requested with size, and rectangular
+// boards with rows and cols. If the dimensions are not valid, the error
+// is written and false is returned
+func (h handler) getDimensions(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	query := r.URL.Query()
+
 	// Get board sizeParam
-	sizeParam := r.URL.Query().Get("size")
+	sizeParam := query.Get("size")
 	if sizeParam == "" {
 		// Default board size
 		sizeParam = "6"
 	}
+	rowsParam, colsParam := query.Get("rows"), query.Get("cols")
+	if rowsParam == "" {
+		rowsParam = sizeParam
+	}
+	if colsParam == "" {
+		colsParam = sizeParam
+	}
 
-	size, err := strconv.Atoi(sizeParam)
+	rows, err := strconv.Atoi(rowsParam)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
+	}
+	cols, err := strconv.Atoi(colsParam)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, 0, false
 	}
 
-	isSizeValid, validationMessage := h.validateSize(size)
+	isSizeValid, validationMessage := ValidateDimensions(rows, cols)
 	if !isSizeValid {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(validationMessage))
-		return
+		return 0, 0, false
 	}
 
-	board, err := h.controller.GenerateBoard(size)
+	return rows, cols, true
+}
+
+func (h handler) generateBoard(
+	w http.ResponseWriter,
+	r *http.Request,
+	rows int,
+	cols int,
+	difficulty Difficulty,
+	variant Variant,
+	seed int64,
+) {
+	board, err := h.controller.GenerateBoard(r.Context(), rows, cols, difficulty, variant, seed)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		h.writeGenerationError(w, err)
 		return
 	}
 
-	marshalledBoard, err := json.Marshal(board)
+	h.writeGame(w, board)
+}
+
+func (h handler) writeGame(w http.ResponseWriter, game Game) {
+	marshalledBoard, err := json.Marshal(game)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -60,6 +217,19 @@ func (h handler) NewGame(w http.ResponseWriter, r *http.Request) {
 	w.Write(marshalledBoard)
 }
 
+// writeGenerationError writes the response for a board that could not be
+// generated or solved. Running out of time is reported as the server
+// being too busy, so the client can try again
+func (h handler) writeGenerationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrGenerationTimeout) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
 // ValidateBoard validates a user's board for correctness
 func (h handler) ValidateBoard(w http.ResponseWriter, r *http.Request) {
 	if r.Body == nil {
@@ -77,11 +247,21 @@ func (h handler) ValidateBoard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate board
-	isValid, invalidHint := h.controller.ValidateBoard(validateRequest.Board)
+	isValid, violations, result, err := h.controller.ValidateBoard(
+		validateRequest.Board,
+		validateRequest.EncryptedState,
+		utils.ClientAddress(r),
+	)
+	if err != nil {
+		h.writeGameError(w, err)
+		return
+	}
 
 	response := ValidateGameResponse{
-		Valid: isValid,
-		Hint:  invalidHint,
+		Valid:      isValid,
+		Hint:       NewInvalidBoardHint(violations),
+		Violations: violations,
+		Result:     result,
 	}
 	marshalledResponse, err := json.Marshal(response)
 	if err != nil {
@@ -92,16 +272,441 @@ func (h handler) ValidateBoard(w http.ResponseWriter, r *http.Request) {
 	w.Write(marshalledResponse)
 }
 
-func (h handler) validateSize(size int) (bool, string) {
-	if size%2 != 0 {
-		return false, "Board size must be even"
+// CheckBoard checks an in-progress board for mistakes. The board isn't
+// tied to an issued game, so checks don't count as hints in its result
+func (h handler) CheckBoard(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var checkRequest CheckBoardRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&checkRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	board := checkRequest.Board
+	if len(board) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(ErrInvalidBoard.Error()))
+		return
+	}
+	isSizeValid, validationMessage := ValidateDimensions(len(board), len(board[0]))
+	if !isSizeValid {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(validationMessage))
+		return
+	}
+
+	result, err := h.controller.CheckBoard(r.Context(), board, checkRequest.Puzzle, checkRequest.Constraints)
+	if errors.Is(err, ErrInvalidBoard) || errors.Is(err, ErrInvalidConstraint) || errors.Is(err, ErrNoUniqueSolution) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	marshalledResult, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledResult)
+}
+
+// Solve solves a partially filled board. The board isn't tied to an
+// issued game, so solving a game's puzzle doesn't count against its
+// result
+func (h handler) Solve(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var solveRequest SolveRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&solveRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	board := solveRequest.Board
+	if len(board) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(ErrInvalidBoard.Error()))
+		return
+	}
+	isSizeValid, validationMessage := ValidateDimensions(len(board), len(board[0]))
+	if !isSizeValid {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(validationMessage))
+		return
+	}
+
+	result, err := h.controller.Solve(r.Context(), board, solveRequest.Constraints)
+	if errors.Is(err, ErrInvalidBoard) || errors.Is(err, ErrInvalidConstraint) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	marshalledResult, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledResult)
+}
+
+// Hint finds the next logical step for a user's board
+func (h handler) Hint(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var hintRequest HintRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&hintRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	hint, found, err := h.controller.Hint(hintRequest.Board, hintRequest.EncryptedState, utils.ClientAddress(r))
+	if err != nil {
+		h.writeGameError(w, err)
+		return
+	}
+
+	marshalledResponse, err := json.Marshal(HintResponse{
+		Found: found,
+		Hint:  hint,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledResponse)
+}
+
+// Import imports a puzzle in the text format so that it can be played
+// like a generated puzzle
+func (h handler) Import(w http.ResponseWriter, r *http.Request) {
+	board, ok := h.readImportedBoard(w, r)
+	if !ok {
+		return
+	}
+
+	game, err := h.controller.ImportPuzzle(r.Context(), board)
+	if errors.Is(err, ErrInvalidBoard) || errors.Is(err, ErrNoUniqueSolution) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	h.writeGame(w, game)
+}
+
+// ImportSolve solves a puzzle in the text format
+func (h handler) ImportSolve(w http.ResponseWriter, r *http.Request) {
+	board, ok := h.readImportedBoard(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.controller.Solve(r.Context(), board, nil)
+	if errors.Is(err, ErrInvalidBoard) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	marshalledResult, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledResult)
+}
+
+// readImportedBoard reads a board in the text format from the request
+// body. If the board can't be read, the error is written and false is
+// returned
+func (h handler) readImportedBoard(w http.ResponseWriter, r *http.Request) ([][]GamePiece, bool) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	text, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	board, err := ParseBoard(string(text))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return nil, false
+	}
+
+	isSizeValid, validationMessage := ValidateDimensions(len(board), len(board[0]))
+	if !isSizeValid {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(validationMessage))
+		return nil, false
+	}
+
+	return board, true
+}
+
+// Render renders a board as an SVG or PNG image
+func (h handler) Render(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
-	if size < 4 {
-		return false, "Minimum board size is 4"
+
+	var renderRequest RenderRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&renderRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
-	if size > 10 {
-		return false, "Maximum board size is 8"
+
+	board := renderRequest.Board
+	if len(board) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(ErrInvalidBoard.Error()))
+		return
+	}
+	isSizeValid, validationMessage := ValidateDimensions(len(board), len(board[0]))
+	if !isSizeValid {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(validationMessage))
+		return
+	}
+
+	options := RenderOptions{
+		Givens:   renderRequest.Givens,
+		Hint:     renderRequest.Hint,
+		CellSize: renderRequest.CellSize,
+	}
+
+	// Render before writing anything, so errors can still be reported
+	var image bytes.Buffer
+	var contentType string
+	switch renderRequest.Format {
+	case SVGFormat, "":
+		contentType = "image/svg+xml"
+		err = RenderSVG(&image, board, options)
+	case PNGFormat:
+		contentType = "image/png"
+		err = RenderPNG(&image, board, options)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Format must be svg or png"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(image.Bytes())
+}
+
+// Booklet generates a printable booklet of puzzles, with the solutions
+// at the back
+func (h handler) Booklet(w http.ResponseWriter, r *http.Request) {
+	rows, cols, ok := h.getDimensions(w, r)
+	if !ok {
+		return
+	}
+	difficulty, ok := h.getDifficulty(w, r)
+	if !ok {
+		return
+	}
+
+	countParam := r.URL.Query().Get("count")
+	if countParam == "" {
+		countParam = "10"
+	}
+	count, err := strconv.Atoi(countParam)
+	if err != nil || count < 1 || count > MaxBookletPuzzles {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("Count must be between 1 and " + strconv.Itoa(MaxBookletPuzzles)))
+		return
+	}
+
+	games, err := h.controller.GenerateBooklet(r.Context(), rows, cols, difficulty, count)
+	if err != nil {
+		h.writeGenerationError(w, err)
+		return
+	}
+
+	title := "Binoku " + strconv.Itoa(rows) + "x" + strconv.Itoa(cols) + " " + string(difficulty) + " puzzles"
+	var booklet bytes.Buffer
+	err = RenderBooklet(&booklet, title, games)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(booklet.Bytes())
+}
+
+// StartSession starts playing an issued game on the server
+func (h handler) StartSession(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var startRequest StartSessionRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&startRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.controller.StartSession(startRequest.EncryptedState, utils.ClientAddress(r))
+	h.writeSession(w, session, err)
+}
+
+// SessionMove makes a move in a session
+func (h handler) SessionMove(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var moveRequest MoveRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&moveRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.controller.MoveSession(r.PathValue("id"), moveRequest.Coordinate, moveRequest.Value)
+	h.writeSession(w, session, err)
+}
+
+// SessionUndo undoes the last move in a session
+func (h handler) SessionUndo(w http.ResponseWriter, r *http.Request) {
+	session, err := h.controller.UndoSession(r.PathValue("id"))
+	h.writeSession(w, session, err)
+}
+
+// SessionRedo redoes the last undone move in a session
+func (h handler) SessionRedo(w http.ResponseWriter, r *http.Request) {
+	session, err := h.controller.RedoSession(r.PathValue("id"))
+	h.writeSession(w, session, err)
+}
+
+// SessionReplay exports every action made in a session
+func (h handler) SessionReplay(w http.ResponseWriter, r *http.Request) {
+	replay, err := h.controller.ReplaySession(r.PathValue("id"))
+	if err != nil {
+		h.writeSessionError(w, err)
+		return
+	}
+
+	marshalledReplay, err := json.Marshal(replay)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledReplay)
+}
+
+// writeSession writes the session, or the error that stopped the session
+// from being changed
+func (h handler) writeSession(w http.ResponseWriter, session Session, err error) {
+	if err != nil {
+		h.writeSessionError(w, err)
+		return
+	}
+
+	marshalledSession, err := json.Marshal(session)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(marshalledSession)
+}
+
+// writeGameError writes the response for an error using an issued game
+func (h handler) writeGameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrGameCompleted):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, ErrTooManyClientGames):
+		w.WriteHeader(http.StatusTooManyRequests)
+	case errors.Is(err, ErrTooManyGames):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	w.Write([]byte(err.Error()))
+}
+
+// writeSessionError writes the response for a session that could not be
+// changed
+func (h handler) writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, ErrNothingToUndo),
+		errors.Is(err, ErrNothingToRedo),
+		errors.Is(err, ErrSessionFinished),
+		errors.Is(err, ErrSessionStarted),
+		errors.Is(err, ErrGameCompleted):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, ErrTooManyClientSessions), errors.Is(err, ErrTooManyClientGames):
+		w.WriteHeader(http.StatusTooManyRequests)
+	case errors.Is(err, ErrTooManySessions), errors.Is(err, ErrTooManyGames):
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case errors.Is(err, ErrInvalidMove), errors.Is(err, ErrInvalidGameState), errors.Is(err, ErrGameExpired):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	return true, ""
+	w.Write([]byte(err.Error()))
 }
@@ -0,0 +1,203 @@
+package binoku
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// techniques are the logical techniques in the order they are tried,
+// from easiest to hardest
+var techniques = []Technique{
+	ConstraintTechnique,
+	PairTechnique,
+	SandwichTechnique,
+	BalanceTechnique,
+	UniquenessTechnique,
+	LineAnalysisTechnique,
+}
+
+// tier gets how hard the technique is, starting at 0 for the easiest
+func (t Technique) tier() int {
+	return slices.Index(techniques, t)
+}
+
+// Techniques gets every technique, from easiest to hardest
+func Techniques() []Technique {
+	return slices.Clone(techniques)
+}
+
+// findHint finds a single empty cell whose value can be logically
+// deduced, preferring the easiest technique
+func findHint(board [][]GamePiece, constraints []Constraint) (Hint, bool) {
+	b := newBitBoard(board, constraints)
+	for _, technique := range techniques {
+		hints := b.deductions(technique, 1)
+		if len(hints) > 0 {
+			return hints[0], true
+		}
+	}
+
+	return Hint{}, false
+}
+
+// deductions finds up to limit empty cells whose values can be deduced
+// using the technique. A limit < 1 finds every deduction
+func (b *bitBoard) deductions(technique Technique, limit int) []Hint {
+	hints := []Hint{}
+	if technique == ConstraintTechnique && b.links == nil {
+		return hints
+	}
+
+	full := uint32(1)<<b.cols - 1
+	for row := range b.rows {
+		empty := ^b.rowFilled[row] & full
+		for empty != 0 {
+			col := bits.TrailingZeros32(empty)
+			empty &^= 1 << col
+
+			value, ok := b.deduce(row, col, technique)
+			if !ok {
+				continue
+			}
+
+			hints = append(hints, Hint{
+				Coordinate: Coordinate{Col: col, Row: row},
+				Value:      value,
+				Rule:       technique,
+			})
+			if len(hints) == limit {
+				return hints
+			}
+		}
+	}
+
+	return hints
+}
+
+// deduce attempts to deduce the value of an empty cell using a single
+// technique. A value is deduced when the technique rules out exactly one
+// of the two possible values
+func (b *bitBoard) deduce(row int, col int, technique Technique) (GamePiece, bool) {
+	zeroRuledOut := b.rulesOut(row, col, 0, technique)
+	oneRuledOut := b.rulesOut(row, col, 1, technique)
+
+	if zeroRuledOut == oneRuledOut {
+		return Empty, false
+	}
+	if zeroRuledOut {
+		return 1, true
+	}
+
+	return 0, true
+}
+
+// rulesOut returns true if the technique proves that value cannot be
+// placed at the cell, checking both the cell's row and column
+func (b *bitBoard) rulesOut(row int, col int, value GamePiece, technique Technique) bool {
+	// Constraints are between cells rather than along lines
+	if technique == ConstraintTechnique {
+		return !b.linksAccept(row, col, value)
+	}
+
+	return lineRulesOut(b.rowOnes, b.rowFilled, row, col, value, b.cols, technique) ||
+		lineRulesOut(b.colOnes, b.colFilled, col, row, value, b.rows, technique)
+}
+
+// lineRulesOut checks if placing value at index of a line breaks a rule
+// that the technique is responsible for. ones and filled hold every line
+// in the same direction, each of which is n cells long
+func lineRulesOut(
+	ones []uint32,
+	filled []uint32,
+	line int,
+	index int,
+	value GamePiece,
+	n int,
+	technique Technique,
+) bool {
+	bit := uint32(1) << index
+	lineOnes := ones[line]
+	lineFilled := filled[line] | bit
+	if value == 1 {
+		lineOnes |= bit
+	}
+	// matching has a bit set for every cell holding value, including index
+	matching := lineOnes
+	if value == 0 {
+		matching = lineFilled &^ lineOnes
+	}
+
+	switch technique {
+	case PairTechnique:
+		// Two of the same value next to each other: xx_ or _xx
+		return (index >= 2 && matching>>(index-2)&0b111 == 0b111) ||
+			(index+2 < n && matching>>index&0b111 == 0b111)
+	case SandwichTechnique:
+		// The same value on either side: x_x
+		return index >= 1 && index+1 < n && matching>>(index-1)&0b111 == 0b111
+	case BalanceTechnique:
+		return !isBalanced(lineOnes, lineFilled, n)
+	case UniquenessTechnique:
+		// If the line has one space left after placing the value, that
+		// space is forced by balance. If that completes a duplicate of
+		// another line, the value can't go here
+		empty := (uint32(1)<<n - 1) &^ lineFilled
+		if bits.OnesCount32(empty) > 1 {
+			return false
+		}
+		if bits.OnesCount32(lineFilled&^lineOnes) >= n/2 {
+			lineOnes |= empty
+		}
+
+		return duplicatesLine(ones, filled, line, lineOnes, n)
+	case LineAnalysisTechnique:
+		// Try every way of completing the line. If none of them work,
+		// the value can't go here
+		return !hasValidCompletion(ones, filled, line, lineOnes, lineFilled, n)
+	}
+
+	return false
+}
+
+// hasValidCompletion returns true if the empty spaces of a line can be
+// filled without breaking any of the rules
+func hasValidCompletion(ones []uint32, filled []uint32, line int, lineOnes uint32, lineFilled uint32, n int) bool {
+	zeros := lineFilled &^ lineOnes
+	if !isBalanced(lineOnes, lineFilled, n) || hasTriple(lineOnes) || hasTriple(zeros) {
+		return false
+	}
+
+	empty := (uint32(1)<<n - 1) &^ lineFilled
+	if empty == 0 {
+		return !duplicatesLine(ones, filled, line, lineOnes, n)
+	}
+
+	// Fill the first empty space with each value in turn
+	bit := uint32(1) << bits.TrailingZeros32(empty)
+	return hasValidCompletion(ones, filled, line, lineOnes, lineFilled|bit, n) ||
+		hasValidCompletion(ones, filled, line, lineOnes|bit, lineFilled|bit, n)
+}
+
+// isBalanced returns true if a line doesn't have more than n/2 of either
+// value
+func isBalanced(lineOnes uint32, lineFilled uint32, n int) bool {
+	return bits.OnesCount32(lineOnes) <= n/2 && bits.OnesCount32(lineFilled&^lineOnes) <= n/2
+}
+
+// hasTriple returns true if a line has three set bits in a row
+func hasTriple(mask uint32) bool {
+	return mask&(mask>>1)&(mask>>2) != 0
+}
+
+// duplicatesLine returns true if a full line with lineOnes would be the
+// same as another full line in the same direction
+func duplicatesLine(ones []uint32, filled []uint32, line int, lineOnes uint32, n int) bool {
+	full := uint32(1)<<n - 1
+	for other := range filled {
+		if other != line && filled[other] == full && ones[other] == lineOnes {
+			return true
+		}
+	}
+
+	return false
+}
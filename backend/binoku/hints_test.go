@@ -0,0 +1,172 @@
+package binoku
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFindHint(t *testing.T) {
+	tests := []struct {
+		name        string
+		board       [][]GamePiece
+		constraints []Constraint
+		expected    Hint
+	}{
+		{
+			name: "pair",
+			board: [][]GamePiece{
+				{0, 0, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 2, Row: 0}, Value: 1, Rule: PairTechnique},
+		},
+		{
+			name: "pair in a column",
+			board: [][]GamePiece{
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, 1},
+				{Empty, Empty, Empty, 1},
+				{Empty, Empty, Empty, Empty},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 3, Row: 0}, Value: 0, Rule: PairTechnique},
+		},
+		{
+			name: "sandwich",
+			board: [][]GamePiece{
+				{1, Empty, 1, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 1, Row: 0}, Value: 0, Rule: SandwichTechnique},
+		},
+		{
+			name: "balance",
+			board: [][]GamePiece{
+				{1, Empty, Empty, 1},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 1, Row: 0}, Value: 0, Rule: BalanceTechnique},
+		},
+		{
+			// A 0 would force the last cell to be 1, copying the first row
+			name: "uniqueness",
+			board: [][]GamePiece{
+				{0, 1, 1, 0, 0, 1},
+				{Empty, Empty, Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty, Empty, Empty},
+				{0, 1, 1, 0, Empty, Empty},
+				{Empty, Empty, Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty, Empty, Empty},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 4, Row: 3}, Value: 1, Rule: UniquenessTechnique},
+		},
+		{
+			name: "constraint",
+			board: [][]GamePiece{
+				{0, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+				{Empty, Empty, Empty, Empty},
+			},
+			constraints: []Constraint{
+				{Type: DifferentConstraint, Cells: [2]Coordinate{{Col: 0, Row: 0}, {Col: 1, Row: 0}}},
+			},
+			expected: Hint{Coordinate: Coordinate{Col: 1, Row: 0}, Value: 1, Rule: ConstraintTechnique},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hint, found := findHint(test.board, test.constraints)
+			if !found || hint != test.expected {
+				t.Errorf("expected %+v, got %+v, %v", test.expected, hint, found)
+			}
+		})
+	}
+}
+
+func TestFindHintNotFound(t *testing.T) {
+	tests := map[string][][]GamePiece{
+		"empty board": {
+			{Empty, Empty, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+		},
+		// Either value can go anywhere without breaking a rule yet
+		"no logical step": {
+			{0, 1, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+			{Empty, Empty, Empty, Empty},
+		},
+		"full board": {
+			{0, 1, 0, 1},
+			{1, 0, 1, 0},
+			{0, 0, 1, 1},
+			{1, 1, 0, 0},
+		},
+	}
+
+	for name, board := range tests {
+		t.Run(name, func(t *testing.T) {
+			hint, found := findHint(board, nil)
+			if found {
+				t.Errorf("expected no hint, got %+v", hint)
+			}
+		})
+	}
+}
+
+// TestHintsNeverGuess follows hints until generated puzzles are solved.
+// Every hint must be the puzzle's only solution for its cell, so no hint
+// is a guess that could need backtracking
+func TestHintsNeverGuess(t *testing.T) {
+	gm := newTestGameManager(t)
+	for _, size := range []int{4, 6, 8, 10} {
+		for _, difficulty := range []Difficulty{EasyDifficulty, MediumDifficulty, HardDifficulty} {
+			for _, variant := range variants {
+				for seed := range int64(3) {
+					game, err := gm.GenerateBoard(context.Background(), size, size, difficulty, variant, seed)
+					if err != nil {
+						t.Fatal(err)
+					}
+					name := fmt.Sprintf("%s %s %dx%d seed %d", difficulty, variant, size, size, seed)
+
+					board := game.Board
+					for range getEmptySpaces(game.Board) {
+						hint, found := findHint(board, game.Constraints)
+						if !found {
+							t.Fatalf("%s: no hint for %v", name, board)
+						}
+
+						cell := hint.Coordinate
+						if board[cell.Row][cell.Col] != Empty || hint.Value != game.solution[cell.Row][cell.Col] {
+							t.Fatalf("%s: hint %+v is a guess", name, hint)
+						}
+						board[cell.Row][cell.Col] = hint.Value
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkGrade(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		puzzle := benchmarkPuzzle(b, size)
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for range b.N {
+				if !gradeBoard(puzzle, nil, LineAnalysisTechnique).Solved {
+					b.Fatal("expected the puzzle to be solved")
+				}
+			}
+		})
+	}
+}
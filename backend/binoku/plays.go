@@ -0,0 +1,139 @@
+package binoku
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// playExpiry is how long an issued game can be played for
+	playExpiry = 24 * time.Hour
+	// maxPlays is the most issued games that can be recorded at once,
+	// which bounds how much memory the records can use
+	maxPlays = 100000
+	// maxPlaysPerClient is the most issued games one client can have
+	// recorded at once, so that a single client can't use up maxPlays and
+	// stop everyone else's games from being recorded
+	maxPlaysPerClient = 500
+	// pruneInterval is how often expired records are removed
+	pruneInterval = time.Minute
+)
+
+// play is the progress of an issued game, recorded on the server so that
+// it can't be undone by submitting an older copy of the encrypted state
+type play struct {
+	lock sync.Mutex
+
+	issuedAt time.Time
+	// client is who first used the game, whose limit the record counts
+	// towards
+	client    string
+	hintsUsed int
+	completed bool
+	// sessionID is the game's session, if one has been started
+	sessionID string
+}
+
+// updatePlay makes a change to the record of the game issued in state
+// while it is locked. The record is created the first time the game is
+// used, by client. Games that have expired or been completed can't be
+// changed
+func (gm gameManager) updatePlay(state GameState, client string, update func(p *play) error) error {
+	if time.Since(state.IssuedAt) > playExpiry {
+		return ErrGameExpired
+	}
+
+	gm.playLock.Lock()
+	p, ok := gm.plays.Get(state.Nonce)
+	if !ok {
+		if gm.clientPlays[client] >= maxPlaysPerClient {
+			gm.playLock.Unlock()
+			return ErrTooManyClientGames
+		}
+		if gm.plays.Size() >= maxPlays {
+			gm.playLock.Unlock()
+			return ErrTooManyGames
+		}
+
+		p = &play{issuedAt: state.IssuedAt, client: client}
+		gm.plays.Put(state.Nonce, p)
+		gm.clientPlays[client]++
+	}
+	gm.playLock.Unlock()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.completed {
+		return ErrGameCompleted
+	}
+
+	return update(p)
+}
+
+// pruneExpired removes the records of expired games and sessions every
+// pruneInterval, until ctx is done
+func (gm gameManager) pruneExpired(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			gm.prune(now)
+		}
+	}
+}
+
+// prune removes the records of games and sessions that have expired by
+// now
+func (gm gameManager) prune(now time.Time) {
+	gm.playLock.Lock()
+	gm.plays.DeleteFunc(func(_ string, p *play) bool {
+		if !p.issuedAt.Before(now.Add(-playExpiry)) {
+			return false
+		}
+
+		gm.clientPlays[p.client]--
+		if gm.clientPlays[p.client] == 0 {
+			delete(gm.clientPlays, p.client)
+		}
+		return true
+	})
+	gm.playLock.Unlock()
+	expired := []*session{}
+	gm.sessionLock.Lock()
+	gm.sessions.DeleteFunc(func(_ string, s *session) bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if !s.lastActive.Before(now.Add(-sessionExpiry)) {
+			return false
+		}
+
+		gm.clientSessions[s.client]--
+		if gm.clientSessions[s.client] == 0 {
+			delete(gm.clientSessions, s.client)
+		}
+		expired = append(expired, s)
+		return true
+	})
+	gm.sessionLock.Unlock()
+
+	// The games of expired sessions can start new ones. Their records are
+	// locked after the sessions are removed, since StartSession locks the
+	// record first
+	for _, s := range expired {
+		p, ok := gm.plays.Get(s.state.Nonce)
+		if !ok {
+			continue
+		}
+
+		p.lock.Lock()
+		if p.sessionID == s.id {
+			p.sessionID = ""
+		}
+		p.lock.Unlock()
+	}
+}
@@ -0,0 +1,176 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_games/utils"
+)
+
+func TestPlays(t *testing.T) {
+	gm := newTestGameManager(t)
+	game, err := gm.GenerateBoard(context.Background(), 6, 6, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := gm.Solve(context.Background(), game.Board, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution := solved.Solutions[0]
+
+	// Hints are recorded on the server, so the same encrypted state can't
+	// be used to get them for free
+	for range 2 {
+		_, found, err := gm.Hint(game.Board, game.EncryptedState, "client")
+		if err != nil || !found {
+			t.Fatalf("expected a hint, got %v, %v", found, err)
+		}
+	}
+
+	// Hints can't be given for a board that changes the puzzle
+	changed := utils.DuplicateMatrix(game.Board)
+changeGiven:
+	for row := range changed {
+		for col := range changed[row] {
+			if changed[row][col] != Empty {
+				changed[row][col] = 1 - changed[row][col]
+				break changeGiven
+			}
+		}
+	}
+	_, _, err = gm.Hint(changed, game.EncryptedState, "client")
+	if !errors.Is(err, ErrGivensChanged) {
+		t.Errorf("expected ErrGivensChanged, got %v", err)
+	}
+
+	valid, _, result, err := gm.ValidateBoard(solution, game.EncryptedState, "client")
+	if err != nil || !valid {
+		t.Fatalf("expected the solution to be valid, got %v, %v", valid, err)
+	}
+	if result.HintsUsed != 2 {
+		t.Errorf("expected 2 hints, got %d", result.HintsUsed)
+	}
+
+	// A completed game can't be scored again or given more hints
+	_, _, _, err = gm.ValidateBoard(solution, game.EncryptedState, "client")
+	if !errors.Is(err, ErrGameCompleted) {
+		t.Errorf("expected ErrGameCompleted, got %v", err)
+	}
+	_, _, err = gm.Hint(game.Board, game.EncryptedState, "client")
+	if !errors.Is(err, ErrGameCompleted) {
+		t.Errorf("expected ErrGameCompleted, got %v", err)
+	}
+
+	// Issuing the game again starts a new play
+	reissued, err := gm.GenerateBoard(context.Background(), 6, 6, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, _, result, err = gm.ValidateBoard(solution, reissued.EncryptedState, "client")
+	if err != nil || !valid || result.HintsUsed != 0 {
+		t.Fatalf("expected a new play, got %v, %+v, %v", valid, result, err)
+	}
+
+	// Old games can't be played
+	state, err := gm.(*gameManager).decryptState(reissued.EncryptedState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.IssuedAt = time.Now().Add(-playExpiry - time.Minute)
+	state.Nonce = utils.NewUUIDString()
+	expired, err := gm.(*gameManager).encryption.Encrypt(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = gm.Hint(game.Board, expired, "client")
+	if !errors.Is(err, ErrGameExpired) {
+		t.Errorf("expected ErrGameExpired, got %v", err)
+	}
+}
+
+func TestTimedGames(t *testing.T) {
+	gm := newTestGameManager(t)
+	ctx := context.Background()
+
+	// Only random puzzles are new to the user, so only they are timed
+	random, err := gm.GenerateRandomBoard(ctx, 6, 6, EasyDifficulty, ClassicVariant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeded, err := gm.GenerateBoard(ctx, 6, 6, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := gm.ImportPuzzle(ctx, seeded.Board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name  string
+		game  Game
+		timed bool
+	}{
+		{name: "random", game: random, timed: true},
+		{name: "seeded", game: seeded, timed: false},
+		{name: "imported", game: imported, timed: false},
+	} {
+		solved, err := gm.Solve(ctx, test.game.Board, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		valid, _, result, err := gm.ValidateBoard(solved.Solutions[0], test.game.EncryptedState, "client")
+		if err != nil || !valid {
+			t.Fatalf("%s: expected the solution to be valid, got %v, %v", test.name, valid, err)
+		}
+		if result.Timed != test.timed || (result.Score > 0) != test.timed {
+			t.Errorf("%s: expected timed to be %v, got %+v", test.name, test.timed, result)
+		}
+	}
+}
+
+func TestPlayLimits(t *testing.T) {
+	gm := newTestGameManager(t).(*gameManager)
+	game, err := gm.GenerateBoard(context.Background(), 4, 4, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hint := func(client string) error {
+		issued, err := gm.issue(game, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = gm.Hint(issued.Board, issued.EncryptedState, client)
+		return err
+	}
+
+	for range maxPlaysPerClient {
+		err = hint("busy")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One client filling its records doesn't stop anyone else playing
+	err = hint("busy")
+	if !errors.Is(err, ErrTooManyClientGames) {
+		t.Errorf("expected ErrTooManyClientGames, got %v", err)
+	}
+	err = hint("other")
+	if err != nil {
+		t.Errorf("expected another client to play, got %v", err)
+	}
+
+	// Expired records no longer count towards the limit
+	gm.prune(time.Now().Add(playExpiry + time.Minute))
+	if gm.plays.Size() != 0 || len(gm.clientPlays) != 0 {
+		t.Errorf("expected every record to be removed, got %d", gm.plays.Size())
+	}
+	err = hint("busy")
+	if err != nil {
+		t.Errorf("expected the client to play again, got %v", err)
+	}
+}
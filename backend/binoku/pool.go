@@ -0,0 +1,129 @@
+package binoku
+
+import (
+	"context"
+	"time"
+	"web_games/utils"
+)
+
+// poolRetryDelay is how long a worker waits after failing to generate a
+// puzzle before trying again
+const poolRetryDelay = time.Second
+
+// poolKey identifies the puzzles that can be served for a request
+type poolKey struct {
+	rows       int
+	cols       int
+	difficulty Difficulty
+	variant    Variant
+}
+
+// generateFunc generates a random puzzle
+type generateFunc func(ctx context.Context, rows int, cols int, difficulty Difficulty, variant Variant) (Game, error)
+
+// puzzlePool holds pre-generated puzzles for each size, difficulty and
+// variant, which background workers keep topped up
+type puzzlePool struct {
+	pools map[poolKey]chan Game
+	// refill wakes up idle workers when a puzzle has been taken
+	refill chan struct{}
+}
+
+// newPuzzlePool creates a puzzle pool and starts its workers. Each
+// worker generates puzzles using generate until ctx is done
+func newPuzzlePool(ctx context.Context, config utils.BinokuConfig, generate generateFunc) *puzzlePool {
+	pool := &puzzlePool{
+		pools:  map[poolKey]chan Game{},
+		refill: make(chan struct{}, 1),
+	}
+
+	if config.PoolSize <= 0 {
+		return pool
+	}
+
+	for _, size := range config.PoolBoardSizes {
+		for difficulty := range difficultyTechniques {
+			for _, variant := range variants {
+				key := poolKey{rows: size, cols: size, difficulty: difficulty, variant: variant}
+				pool.pools[key] = make(chan Game, config.PoolSize)
+			}
+		}
+	}
+
+	for range config.PoolWorkers {
+		go pool.work(ctx, generate)
+	}
+
+	return pool
+}
+
+// take takes a pre-generated puzzle. Returns false if there isn't one
+func (p *puzzlePool) take(rows int, cols int, difficulty Difficulty, variant Variant) (Game, bool) {
+	games, ok := p.pools[poolKey{rows: rows, cols: cols, difficulty: difficulty, variant: variant}]
+	if !ok {
+		return Game{}, false
+	}
+
+	select {
+	case game := <-games:
+		// Let the workers know there is room, without blocking if they
+		// already know
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+		return game, true
+	default:
+		return Game{}, false
+	}
+}
+
+// work keeps generating puzzles for whichever pool is emptiest, until
+// ctx is done
+func (p *puzzlePool) work(ctx context.Context, generate generateFunc) {
+	for ctx.Err() == nil {
+		key, ok := p.emptiest()
+		if !ok {
+			// Every pool is full, wait until one isn't
+			select {
+			case <-p.refill:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		game, err := generate(ctx, key.rows, key.cols, key.difficulty, key.variant)
+		if err != nil {
+			select {
+			case <-time.After(poolRetryDelay):
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// Another worker may have filled the pool in the meantime
+		select {
+		case p.pools[key] <- game:
+		default:
+		}
+	}
+}
+
+// emptiest finds the pool with the fewest puzzles. Returns false if
+// every pool is full
+func (p *puzzlePool) emptiest() (poolKey, bool) {
+	var emptiestKey poolKey
+	emptiestCount := -1
+	for key, games := range p.pools {
+		if len(games) == cap(games) {
+			continue
+		}
+
+		if emptiestCount < 0 || len(games) < emptiestCount {
+			emptiestKey = key
+			emptiestCount = len(games)
+		}
+	}
+
+	return emptiestKey, emptiestCount >= 0
+}
@@ -0,0 +1,56 @@
+package binoku
+
+import (
+	"context"
+	"testing"
+	"time"
+	"web_games/utils"
+)
+
+func TestPuzzlePool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without any workers of its own, so that the test can run one
+	pool := newPuzzlePool(ctx, utils.BinokuConfig{}, nil)
+	for _, variant := range variants {
+		pool.pools[poolKey{rows: 4, cols: 4, difficulty: EasyDifficulty, variant: variant}] = make(chan Game, 1)
+	}
+
+	generated := make(chan poolKey)
+	done := make(chan struct{})
+	go func() {
+		pool.work(ctx, func(ctx context.Context, rows int, cols int, difficulty Difficulty, variant Variant) (Game, error) {
+			generated <- poolKey{rows: rows, cols: cols, difficulty: difficulty, variant: variant}
+			return Game{Variant: variant}, nil
+		})
+		close(done)
+	}()
+
+	// The worker fills every pool, then waits for a puzzle to be taken
+	for range variants {
+		<-generated
+	}
+	for {
+		if _, ok := pool.emptiest(); !ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	game, ok := pool.take(4, 4, EasyDifficulty, PlusVariant)
+	if !ok || game.Variant != PlusVariant {
+		t.Fatalf("expected a plus puzzle, got %+v, %v", game, ok)
+	}
+	key := <-generated
+	if key.variant != PlusVariant {
+		t.Errorf("expected the plus pool to be refilled, got %+v", key)
+	}
+
+	// Cancelling stops the worker while it is waiting
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the worker to stop")
+	}
+}
@@ -0,0 +1,240 @@
+package binoku
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidCellSize is returned when a board is rendered with a cell
+// size outside of MinCellSize and MaxCellSize
+var ErrInvalidCellSize = errors.New("Invalid cell size")
+
+const (
+	// DefaultCellSize is the width and height of each cell in pixels,
+	// unless another size is given
+	DefaultCellSize = 48
+	// MinCellSize is the smallest cell size a board can be rendered at
+	MinCellSize = 8
+	// MaxCellSize is the largest cell size a board can be rendered at
+	MaxCellSize = 128
+)
+
+// The colours used to render a board, which match the website
+var (
+	backgroundColor = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	emptyColor      = color.RGBA{0xDA, 0xDA, 0xD9, 0xFF}
+	borderColor     = color.RGBA{0x36, 0x38, 0x2E, 0xFF}
+	zeroColor       = color.RGBA{0x21, 0x9E, 0xBC, 0xFF}
+	oneColor        = color.RGBA{0xFB, 0x85, 0x00, 0xFF}
+	hintColor       = color.RGBA{0xFF, 0x00, 0x00, 0xFF}
+)
+
+// RenderOptions is how a board should be rendered
+type RenderOptions struct {
+	// Givens is the puzzle the board was started from. Filled cells in
+	// Givens are marked, to tell them apart from the player's entries. If
+	// Givens is nil, every filled cell in the board is a given
+	Givens [][]GamePiece
+	// Hint is the rows and columns to highlight
+	Hint InvalidBoardHint
+	// CellSize is the width and height of each cell in pixels. Zero uses
+	// DefaultCellSize
+	CellSize int
+}
+
+// renderedCell is a cell of the board as it should be drawn
+type renderedCell struct {
+	bounds      image.Rectangle
+	value       GamePiece
+	given       bool
+	highlighted bool
+}
+
+// boardLayout is the position and appearance of every cell on a board,
+// shared by every image format so they always look the same
+type boardLayout struct {
+	width    int
+	height   int
+	cellSize int
+	cells    []renderedCell
+}
+
+// newBoardLayout lays out the board, checking that the options fit it
+func newBoardLayout(board [][]GamePiece, options RenderOptions) (boardLayout, error) {
+	err := validateBoardShape(board)
+	if err != nil {
+		return boardLayout{}, err
+	}
+
+	givens := options.Givens
+	if givens == nil {
+		givens = board
+	}
+	if len(givens) != len(board) {
+		return boardLayout{}, ErrInvalidBoard
+	}
+	for row := range givens {
+		if len(givens[row]) != len(board[row]) {
+			return boardLayout{}, ErrInvalidBoard
+		}
+	}
+
+	cellSize := options.CellSize
+	if cellSize == 0 {
+		cellSize = DefaultCellSize
+	}
+	if cellSize < MinCellSize || cellSize > MaxCellSize {
+		return boardLayout{}, ErrInvalidCellSize
+	}
+
+	rows, cols := len(board), len(board[0])
+	margin := cellSize / 4
+	layout := boardLayout{
+		width:    cols*cellSize + 2*margin,
+		height:   rows*cellSize + 2*margin,
+		cellSize: cellSize,
+	}
+	for row := range rows {
+		for col := range cols {
+			minPoint := image.Pt(margin+col*cellSize, margin+row*cellSize)
+			layout.cells = append(layout.cells, renderedCell{
+				bounds:      image.Rectangle{Min: minPoint, Max: minPoint.Add(image.Pt(cellSize, cellSize))},
+				value:       board[row][col],
+				given:       givens[row][col] != Empty,
+				highlighted: slices.Contains(options.Hint.Rows, row) || slices.Contains(options.Hint.Cols, col),
+			})
+		}
+	}
+
+	return layout, nil
+}
+
+// fill gets the colour a cell is filled with
+func (c renderedCell) fill() color.RGBA {
+	switch c.value {
+	case 0:
+		return zeroColor
+	case 1:
+		return oneColor
+	default:
+		return emptyColor
+	}
+}
+
+// hintWidth is how thick the highlight around hinted cells is
+func (l boardLayout) hintWidth() int {
+	return max(l.cellSize/16, 1)
+}
+
+// givenMarker is the square in the corner of a cell that marks it as
+// a given
+func (l boardLayout) givenMarker(cell renderedCell) image.Rectangle {
+	size := max(l.cellSize/6, 2)
+	gap := l.cellSize / 8
+	minPoint := image.Pt(cell.bounds.Max.X-gap-size, cell.bounds.Min.Y+gap)
+
+	return image.Rectangle{Min: minPoint, Max: minPoint.Add(image.Pt(size, size))}
+}
+
+// RenderSVG renders a board as an SVG image
+func RenderSVG(w io.Writer, board [][]GamePiece, options RenderOptions) error {
+	layout, err := newBoardLayout(board, options)
+	if err != nil {
+		return err
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(
+		&svg,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		layout.width, layout.height, layout.width, layout.height,
+	)
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(backgroundColor))
+
+	hintWidth := layout.hintWidth()
+	for _, cell := range layout.cells {
+		bounds := cell.bounds
+		fmt.Fprintf(
+			&svg,
+			`<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s" stroke-width="1"/>`+"\n",
+			bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), hexColor(cell.fill()), hexColor(borderColor),
+		)
+
+		if cell.highlighted {
+			// Strokes are centred on the edge, so move them inside the cell
+			inset := float64(hintWidth)/2 + 1
+			fmt.Fprintf(
+				&svg,
+				`<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="%s" stroke-width="%d"/>`+"\n",
+				float64(bounds.Min.X)+inset, float64(bounds.Min.Y)+inset,
+				float64(bounds.Dx())-2*inset, float64(bounds.Dy())-2*inset,
+				hexColor(hintColor), hintWidth,
+			)
+		}
+
+		if cell.given && cell.value != Empty {
+			marker := layout.givenMarker(cell)
+			fmt.Fprintf(
+				&svg,
+				`<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+				marker.Min.X, marker.Min.Y, marker.Dx(), marker.Dy(), hexColor(borderColor),
+			)
+		}
+	}
+	svg.WriteString("</svg>\n")
+
+	_, err = io.WriteString(w, svg.String())
+	return err
+}
+
+// RenderPNG renders a board as a PNG image
+func RenderPNG(w io.Writer, board [][]GamePiece, options RenderOptions) error {
+	layout, err := newBoardLayout(board, options)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, layout.width, layout.height))
+	fillRect(img, img.Bounds(), backgroundColor)
+
+	hintWidth := layout.hintWidth()
+	for _, cell := range layout.cells {
+		fillRect(img, cell.bounds, cell.fill())
+		strokeRect(img, cell.bounds, 1, borderColor)
+
+		if cell.highlighted {
+			strokeRect(img, cell.bounds.Inset(1), hintWidth, hintColor)
+		}
+
+		if cell.given && cell.value != Empty {
+			fillRect(img, layout.givenMarker(cell), borderColor)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// fillRect fills a rectangle of the image with a colour
+func fillRect(img draw.Image, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// strokeRect draws a border of the given width just inside a rectangle
+func strokeRect(img draw.Image, rect image.Rectangle, width int, c color.Color) {
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+width), c)
+	fillRect(img, image.Rect(rect.Min.X, rect.Max.Y-width, rect.Max.X, rect.Max.Y), c)
+	fillRect(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+width, rect.Max.Y), c)
+	fillRect(img, image.Rect(rect.Max.X-width, rect.Min.Y, rect.Max.X, rect.Max.Y), c)
+}
+
+// hexColor formats a colour for use in an SVG
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
@@ -0,0 +1,72 @@
+package binoku
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+var renderBoard = [][]GamePiece{
+	{0, 1, Empty, 1},
+	{Empty, Empty, 0, 0},
+	{1, 0, 1, Empty},
+	{Empty, 1, Empty, Empty},
+}
+
+func TestRenderPNG(t *testing.T) {
+	var image bytes.Buffer
+	err := RenderPNG(&image, renderBoard, RenderOptions{CellSize: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := png.Decode(&image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 cells and a margin of a quarter of a cell on each side
+	if bounds := decoded.Bounds(); bounds.Dx() != 90 || bounds.Dy() != 90 {
+		t.Errorf("expected a 90x90 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	givens := [][]GamePiece{
+		{0, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+		{Empty, Empty, Empty, Empty},
+	}
+
+	var image strings.Builder
+	err := RenderSVG(&image, renderBoard, RenderOptions{
+		Givens: givens,
+		Hint:   InvalidBoardHint{Rows: []int{1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := image.String()
+	if got := strings.Count(svg, `stroke="`+hexColor(hintColor)+`"`); got != 4 {
+		t.Errorf("expected 4 highlighted cells, got %d", got)
+	}
+	if got := strings.Count(svg, `fill="`+hexColor(borderColor)+`"/>`); got != 1 {
+		t.Errorf("expected 1 given, got %d", got)
+	}
+}
+
+func TestRenderInvalidOptions(t *testing.T) {
+	var image bytes.Buffer
+	err := RenderSVG(&image, renderBoard, RenderOptions{CellSize: MaxCellSize + 1})
+	if !errors.Is(err, ErrInvalidCellSize) {
+		t.Errorf("expected ErrInvalidCellSize, got %v", err)
+	}
+
+	err = RenderPNG(&image, renderBoard, RenderOptions{Givens: renderBoard[:2]})
+	if !errors.Is(err, ErrInvalidBoard) {
+		t.Errorf("expected ErrInvalidBoard, got %v", err)
+	}
+}
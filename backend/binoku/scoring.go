@@ -0,0 +1,50 @@
+package binoku
+
+import "time"
+
+const (
+	// pointsPerDifficulty is how many points each point of a puzzle's
+	// difficulty score is worth
+	pointsPerDifficulty = 10
+	// parSecondsPerDifficulty is how long a puzzle is expected to take
+	// for each point of its difficulty score. Solving a puzzle within
+	// par earns every point, after which points are lost the longer it
+	// takes
+	parSecondsPerDifficulty = 3
+	// minimumParSeconds stops tiny puzzles having an unrealistic par
+	minimumParSeconds = 30
+	// hintPenaltyPercent is the percentage of points lost for each hint
+	hintPenaltyPercent = 10
+)
+
+// newGameResult calculates the result of a game that was solved at
+// solvedAt, after using hintsUsed hints
+func newGameResult(state GameState, hintsUsed int, solvedAt time.Time) GameResult {
+	elapsedSeconds := max(int(solvedAt.Sub(state.IssuedAt).Seconds()), 0)
+
+	result := GameResult{
+		ElapsedSeconds: elapsedSeconds,
+		HintsUsed:      hintsUsed,
+		Timed:          state.Timed,
+	}
+	if state.Timed {
+		result.Score = calculateScore(state.DifficultyScore, elapsedSeconds, hintsUsed)
+	}
+
+	return result
+}
+
+// calculateScore scores a solved puzzle. Harder puzzles are worth more,
+// taking longer than par scales the score down, and each hint takes off
+// a percentage of the points
+func calculateScore(difficultyScore int, elapsedSeconds int, hintsUsed int) int {
+	score := difficultyScore * pointsPerDifficulty
+
+	parSeconds := max(difficultyScore*parSecondsPerDifficulty, minimumParSeconds)
+	if elapsedSeconds > parSeconds {
+		score = score * parSeconds / elapsedSeconds
+	}
+
+	penaltyPercent := min(hintsUsed*hintPenaltyPercent, 100)
+	return score * (100 - penaltyPercent) / 100
+}
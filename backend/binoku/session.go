@@ -0,0 +1,265 @@
+package binoku
+
+import (
+	"slices"
+	"sync"
+	"time"
+	"web_games/utils"
+)
+
+const (
+	// sessionExpiry is how long a session is kept after its last action.
+	// It is kept short so that abandoned sessions free up room quickly
+	sessionExpiry = 30 * time.Minute
+	// maxSessionActions is the most actions a session can have, which
+	// bounds how much memory a session can use
+	maxSessionActions = 2000
+	// maxSessions is the most sessions there can be at once
+	maxSessions = 1000
+	// maxSessionsPerClient is the most sessions one client can have at
+	// once, so that a single client can't use up maxSessions
+	maxSessionsPerClient = 10
+)
+
+// session is a game being played on the server, with a log of every
+// action made to its board
+type session struct {
+	lock sync.Mutex
+
+	id string
+	// client is who started the session, whose limit it counts towards
+	client    string
+	state     GameState
+	board     [][]GamePiece
+	startedAt time.Time
+	// lastActive is when the session was last changed, to know when it
+	// has expired
+	lastActive time.Time
+	// solvedAt is set the first time the board is solved
+	solvedAt time.Time
+
+	// moves are the moves that can be undone or redone. The first
+	// applied moves are on the board and the rest have been undone
+	moves   []SessionAction
+	applied int
+	// actions is every action made, including undos and redos
+	actions []SessionAction
+}
+
+// newSession starts a session for the game in state, for client
+func newSession(state GameState, client string) *session {
+	now := time.Now()
+	return &session{
+		id:         utils.NewUUIDString(),
+		client:     client,
+		state:      state,
+		board:      utils.DuplicateMatrix(state.Givens),
+		startedAt:  now,
+		lastActive: now,
+		moves:      []SessionAction{},
+		actions:    []SessionAction{},
+	}
+}
+
+// move places value in a cell. Any undone moves can no longer be redone
+func (s *session) move(coordinate Coordinate, value GamePiece) error {
+	if err := s.checkActive(); err != nil {
+		return err
+	}
+
+	rows, cols := len(s.board), len(s.board[0])
+	if coordinate.Row < 0 || coordinate.Row >= rows || coordinate.Col < 0 || coordinate.Col >= cols {
+		return ErrInvalidMove
+	}
+	if value != Empty && value != 0 && value != 1 {
+		return ErrInvalidMove
+	}
+	previous := s.board[coordinate.Row][coordinate.Col]
+	if s.state.Givens[coordinate.Row][coordinate.Col] != Empty || previous == value {
+		return ErrInvalidMove
+	}
+
+	move := s.record(MoveAction, coordinate, value, previous)
+	s.moves = append(s.moves[:s.applied], move)
+	s.applied++
+
+	return nil
+}
+
+// undo reverts the last applied move
+func (s *session) undo() error {
+	if err := s.checkActive(); err != nil {
+		return err
+	}
+	if s.applied == 0 {
+		return ErrNothingToUndo
+	}
+
+	s.applied--
+	move := s.moves[s.applied]
+	s.record(UndoAction, move.Coordinate, move.Previous, move.Value)
+
+	return nil
+}
+
+// redo reapplies the last undone move
+func (s *session) redo() error {
+	if err := s.checkActive(); err != nil {
+		return err
+	}
+	if s.applied == len(s.moves) {
+		return ErrNothingToRedo
+	}
+
+	move := s.moves[s.applied]
+	s.applied++
+	s.record(RedoAction, move.Coordinate, move.Value, move.Previous)
+
+	return nil
+}
+
+// checkActive returns ErrSessionFinished if the session can't be changed
+func (s *session) checkActive() error {
+	if !s.solvedAt.IsZero() || len(s.actions) >= maxSessionActions {
+		return ErrSessionFinished
+	}
+
+	return nil
+}
+
+// record applies a change to the board and adds it to the action log
+func (s *session) record(actionType SessionActionType, coordinate Coordinate, value GamePiece, previous GamePiece) SessionAction {
+	now := time.Now()
+	action := SessionAction{
+		Type:       actionType,
+		Coordinate: coordinate,
+		Value:      value,
+		Previous:   previous,
+		ElapsedMs:  now.Sub(s.startedAt).Milliseconds(),
+	}
+	s.board[coordinate.Row][coordinate.Col] = value
+	s.actions = append(s.actions, action)
+	s.lastActive = now
+
+	// The solution is unique, so matching it means the board is solved
+	if slices.EqualFunc(s.board, s.state.Solution, slices.Equal) {
+		s.solvedAt = now
+	}
+
+	return action
+}
+
+// view gets the current state of the session
+func (s *session) view() Session {
+	return Session{
+		ID:          s.id,
+		Board:       utils.DuplicateMatrix(s.board),
+		Constraints: s.state.Constraints,
+		CanUndo:     s.checkActive() == nil && s.applied > 0,
+		CanRedo:     s.checkActive() == nil && s.applied < len(s.moves),
+		Actions:     len(s.actions),
+		Solved:      !s.solvedAt.IsZero(),
+	}
+}
+
+// replay gets everything needed to replay the session
+func (s *session) replay() SessionReplay {
+	replay := SessionReplay{
+		ID:          s.id,
+		Givens:      s.state.Givens,
+		Constraints: s.state.Constraints,
+		StartedAt:   s.startedAt,
+		Actions:     slices.Clone(s.actions),
+		Board:       utils.DuplicateMatrix(s.board),
+		Solved:      !s.solvedAt.IsZero(),
+	}
+	if replay.Solved {
+		replay.SolvedAfterMs = s.solvedAt.Sub(s.startedAt).Milliseconds()
+	}
+
+	return replay
+}
+
+// StartSession starts a session for an issued game, so that its moves
+// can be made on the server. Each issued game can only have one session
+// at a time, so another can only be started once it has expired. The
+// game is recorded against client if it hasn't been used before
+func (gm gameManager) StartSession(encryptedState string, client string) (Session, error) {
+	state, err := gm.decryptState(encryptedState)
+	if err != nil {
+		return Session{}, err
+	}
+
+	s := newSession(state, client)
+	err = gm.updatePlay(state, client, func(p *play) error {
+		if p.sessionID != "" {
+			return ErrSessionStarted
+		}
+
+		gm.sessionLock.Lock()
+		defer gm.sessionLock.Unlock()
+		if gm.clientSessions[client] >= maxSessionsPerClient {
+			return ErrTooManyClientSessions
+		}
+		if gm.sessions.Size() >= maxSessions {
+			return ErrTooManySessions
+		}
+
+		gm.sessions.Put(s.id, s)
+		gm.clientSessions[client]++
+		p.sessionID = s.id
+		return nil
+	})
+	if err != nil {
+		return Session{}, err
+	}
+
+	return s.view(), nil
+}
+
+// MoveSession places value in a cell of a session's board
+func (gm gameManager) MoveSession(id string, coordinate Coordinate, value GamePiece) (Session, error) {
+	return gm.updateSession(id, func(s *session) error {
+		return s.move(coordinate, value)
+	})
+}
+
+// UndoSession reverts the last move of a session
+func (gm gameManager) UndoSession(id string) (Session, error) {
+	return gm.updateSession(id, (*session).undo)
+}
+
+// RedoSession reapplies the last undone move of a session
+func (gm gameManager) RedoSession(id string) (Session, error) {
+	return gm.updateSession(id, (*session).redo)
+}
+
+// ReplaySession exports a session's action log
+func (gm gameManager) ReplaySession(id string) (SessionReplay, error) {
+	s, ok := gm.sessions.Get(id)
+	if !ok {
+		return SessionReplay{}, ErrSessionNotFound
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.replay(), nil
+}
+
+// updateSession makes a change to a session while it is locked
+func (gm gameManager) updateSession(id string, update func(s *session) error) (Session, error) {
+	s, ok := gm.sessions.Get(id)
+	if !ok {
+		return Session{}, ErrSessionNotFound
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := update(s)
+	if err != nil {
+		return Session{}, err
+	}
+
+	return s.view(), nil
+}
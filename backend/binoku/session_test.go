@@ -0,0 +1,170 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+	"web_games/utils"
+)
+
+func TestSession(t *testing.T) {
+	gm := newTestGameManager(t)
+	game, err := gm.GenerateBoard(context.Background(), 4, 4, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := gm.Solve(context.Background(), game.Board, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution := solved.Solutions[0]
+
+	session, err := gm.StartSession(game.EncryptedState, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empties := getEmptySpaces(game.Board)
+	first := empties[0]
+	wrong := 1 - solution[first.Row][first.Col]
+
+	// Givens can't be changed
+	for row := range game.Board {
+		for col := range game.Board[row] {
+			if game.Board[row][col] != Empty {
+				_, err = gm.MoveSession(session.ID, Coordinate{Col: col, Row: row}, wrong)
+				if !errors.Is(err, ErrInvalidMove) {
+					t.Fatalf("expected ErrInvalidMove for a given, got %v", err)
+				}
+			}
+		}
+	}
+
+	// Make a wrong move, undo it and redo it
+	_, err = gm.MoveSession(session.ID, first, wrong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err = gm.UndoSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Board[first.Row][first.Col] != Empty || !session.CanRedo {
+		t.Fatal("undo should empty the cell and allow a redo")
+	}
+	session, err = gm.RedoSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Board[first.Row][first.Col] != wrong {
+		t.Fatal("redo should restore the move")
+	}
+
+	// A new move after an undo stops the undone move being redone
+	_, err = gm.UndoSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cell := range empties {
+		session, err = gm.MoveSession(session.ID, cell, solution[cell.Row][cell.Col])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !session.Solved || session.CanRedo || session.CanUndo {
+		t.Fatalf("expected a finished session, got %+v", session)
+	}
+	_, err = gm.UndoSession(session.ID)
+	if !errors.Is(err, ErrSessionFinished) {
+		t.Errorf("expected ErrSessionFinished, got %v", err)
+	}
+
+	replay, err := gm.ReplaySession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The wrong move, undo, redo and undo, then every solving move
+	if len(replay.Actions) != 4+len(empties) {
+		t.Errorf("expected %d actions, got %d", 4+len(empties), len(replay.Actions))
+	}
+	board := utils.DuplicateMatrix(replay.Givens)
+	for _, action := range replay.Actions {
+		board[action.Coordinate.Row][action.Coordinate.Col] = action.Value
+	}
+	if FormatBoard(board) != FormatBoard(solution) || !replay.Solved {
+		t.Error("replaying every action should solve the board")
+	}
+
+	_, err = gm.ReplaySession("missing")
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSessionLimits(t *testing.T) {
+	gm := newTestGameManager(t).(*gameManager)
+	game, err := gm.GenerateBoard(context.Background(), 4, 4, EasyDifficulty, ClassicVariant, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each issued game can only have one session
+	session, err := gm.StartSession(game.EncryptedState, "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = gm.StartSession(game.EncryptedState, "client")
+	if !errors.Is(err, ErrSessionStarted) {
+		t.Errorf("expected ErrSessionStarted, got %v", err)
+	}
+
+	start := func(client string) error {
+		issued, err := gm.issue(game, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = gm.StartSession(issued.EncryptedState, client)
+		return err
+	}
+
+	// One client can't use up every session
+	for range maxSessionsPerClient - 1 {
+		err = start("client")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = start("client")
+	if !errors.Is(err, ErrTooManyClientSessions) {
+		t.Errorf("expected ErrTooManyClientSessions, got %v", err)
+	}
+
+	for i := range maxSessions - maxSessionsPerClient {
+		err = start(strconv.Itoa(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = start("other")
+	if !errors.Is(err, ErrTooManySessions) {
+		t.Errorf("expected ErrTooManySessions, got %v", err)
+	}
+
+	// Sessions expire once they haven't been used for a while, which makes
+	// room for new ones, and lets their games start a new session
+	gm.prune(time.Now().Add(sessionExpiry + time.Minute))
+	_, err = gm.ReplaySession(session.ID)
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound, got %v", err)
+	}
+	err = start("client")
+	if err != nil {
+		t.Errorf("expected a session to be free, got %v", err)
+	}
+	restarted, err := gm.StartSession(game.EncryptedState, "client")
+	if err != nil || restarted.ID == session.ID {
+		t.Errorf("expected the game to start a new session, got %+v, %v", restarted, err)
+	}
+}
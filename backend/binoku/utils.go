@@ -0,0 +1,180 @@
+package binoku
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPuzzleID is returned when a puzzle ID cannot be parsed
+var ErrInvalidPuzzleID = errors.New("Invalid puzzle ID")
+
+// puzzleIDSeparator separates the parts of a puzzle ID
+const puzzleIDSeparator = "-"
+
+// variantPrefixes starts the puzzle ID of every variant other than
+// ClassicVariant, which has no prefix so older IDs still work
+var variantPrefixes = map[Variant]string{
+	PlusVariant: "p",
+}
+
+// NewSeed creates a new random generation seed
+func NewSeed() int64 {
+	return rand.Int63()
+}
+
+// DailySeed derives the generation seed for the daily puzzle of
+// the provided date. Every time on the same UTC date has the same seed
+func DailySeed(date time.Time) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(date.UTC().Format(time.DateOnly)))
+
+	// Keep the seed positive so it stays compatible with puzzle IDs
+	return int64(hash.Sum64() & math.MaxInt64)
+}
+
+// MaxDistinctLines is how many distinct lines of the given length follow
+// the rules, which limits how many lines of that length a board can have
+func MaxDistinctLines(length int) int {
+	count := 0
+	line := make([]GamePiece, length)
+	var countLines func(index int)
+	countLines = func(index int) {
+		if !validateRuleOne(line) || !validateRuleTwo(line) {
+			return
+		}
+		if index == length {
+			count++
+			return
+		}
+
+		for _, value := range []GamePiece{0, 1} {
+			line[index] = value
+			countLines(index + 1)
+			line[index] = Empty
+		}
+	}
+
+	for i := range line {
+		line[i] = Empty
+	}
+	countLines(0)
+
+	return count
+}
+
+// NewPuzzleID encodes the inputs of a generated puzzle into a compact,
+// shareable ID, e.g. "6-m-1y2p0ij32e8e7". Rectangular boards include
+// both dimensions, e.g. "6x10-m-1y2p0ij32e8e7", and variants are
+// prefixed, e.g. "p-6-m-1y2p0ij32e8e7"
+func NewPuzzleID(rows int, cols int, difficulty Difficulty, variant Variant, seed int64) string {
+	size := strconv.Itoa(rows)
+	if rows != cols {
+		size = fmt.Sprintf("%dx%d", rows, cols)
+	}
+
+	parts := []string{
+		size,
+		string(difficulty)[:1],
+		strconv.FormatInt(seed, 36),
+	}
+	if prefix, ok := variantPrefixes[variant]; ok {
+		parts = append([]string{prefix}, parts...)
+	}
+
+	return strings.Join(parts, puzzleIDSeparator)
+}
+
+// ParsePuzzleID decodes a puzzle ID created by NewPuzzleID into its
+// rows, columns, difficulty, variant and seed
+func ParsePuzzleID(id string) (int, int, Difficulty, Variant, int64, error) {
+	parts := strings.Split(id, puzzleIDSeparator)
+
+	variant := ClassicVariant
+	if len(parts) == 4 {
+		variant = ""
+		for v, prefix := range variantPrefixes {
+			if prefix == parts[0] {
+				variant = v
+			}
+		}
+		if variant == "" {
+			return 0, 0, "", "", 0, ErrInvalidPuzzleID
+		}
+		parts = parts[1:]
+	}
+	if len(parts) != 3 {
+		return 0, 0, "", "", 0, ErrInvalidPuzzleID
+	}
+
+	rowsPart, colsPart, isRectangular := strings.Cut(parts[0], "x")
+	if !isRectangular {
+		colsPart = rowsPart
+	}
+	rows, err := strconv.Atoi(rowsPart)
+	if err != nil {
+		return 0, 0, "", "", 0, ErrInvalidPuzzleID
+	}
+	cols, err := strconv.Atoi(colsPart)
+	if err != nil {
+		return 0, 0, "", "", 0, ErrInvalidPuzzleID
+	}
+
+	var difficulty Difficulty
+	for d := range difficultyTechniques {
+		if string(d)[:1] == parts[1] {
+			difficulty = d
+		}
+	}
+	if difficulty == "" {
+		return 0, 0, "", "", 0, ErrInvalidPuzzleID
+	}
+
+	seed, err := strconv.ParseInt(parts[2], 36, 64)
+	if err != nil {
+		return 0, 0, "", "", 0, ErrInvalidPuzzleID
+	}
+
+	return rows, cols, difficulty, variant, seed, nil
+}
+
+// ValidateDimensions validates the number of rows and columns of a
+// board. If they are not valid, the reason is returned
+func ValidateDimensions(rows int, cols int) (bool, string) {
+	isValid, message := validateSize(rows)
+	if !isValid {
+		return false, "Rows: " + message
+	}
+
+	isValid, message = validateSize(cols)
+	if !isValid {
+		return false, "Columns: " + message
+	}
+
+	// Every row and every column must be different
+	if cols > MaxDistinctLines(rows) || rows > MaxDistinctLines(cols) {
+		return false, ErrImpossibleDimensions.Error()
+	}
+
+	return true, ""
+}
+
+// validateSize validates the length of a single side of a board
+func validateSize(size int) (bool, string) {
+	if size%2 != 0 {
+		return false, "Board size must be even"
+	}
+	if size < 4 {
+		return false, "Minimum board size is 4"
+	}
+	if size > 14 {
+		return false, "Maximum board size is 14"
+	}
+
+	return true, ""
+}
@@ -0,0 +1,99 @@
+package binoku
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParsePuzzleID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		// parses is true if the ID can be parsed, but has dimensions that
+		// can't be generated
+		parses bool
+	}{
+		{name: "empty", id: ""},
+		{name: "too few parts", id: "6-m"},
+		{name: "too many parts", id: "p-6-m-1-2"},
+		{name: "bad size", id: "six-m-1"},
+		{name: "bad columns", id: "6x-m-1"},
+		{name: "odd size", id: "7-m-1", parses: true},
+		{name: "odd columns", id: "6x7-m-1", parses: true},
+		{name: "too large", id: "16-m-1", parses: true},
+		{name: "unknown difficulty", id: "6-x-1"},
+		{name: "unknown variant", id: "q-6-m-1"},
+		{name: "seed not base 36", id: "6-m-1y2p_0"},
+		{name: "seed too large", id: "6-m-zzzzzzzzzzzzzzzz"},
+	}
+
+	h := NewHandler(newTestGameManager(t))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, _, _, _, err := ParsePuzzleID(test.id)
+			if test.parses && err != nil {
+				t.Errorf("expected %q to parse, got %v", test.id, err)
+			}
+			if !test.parses && !errors.Is(err, ErrInvalidPuzzleID) {
+				t.Errorf("expected ErrInvalidPuzzleID, got %v", err)
+			}
+
+			// Every malformed ID is the client's mistake
+			r := httptest.NewRequest(http.MethodGet, "/binoku/puzzle/"+test.id, nil)
+			r.SetPathValue("id", test.id)
+			w := httptest.NewRecorder()
+			h.Puzzle(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestDailySeed(t *testing.T) {
+	day := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	seed := DailySeed(day)
+
+	// Every time on the same UTC date has the same seed, wherever it is
+	sameDay := []time.Time{
+		day.Add(23*time.Hour + 59*time.Minute),
+		time.Date(2024, time.March, 4, 20, 0, 0, 0, time.FixedZone("EST", -5*60*60)),
+		time.Date(2024, time.March, 6, 8, 0, 0, 0, time.FixedZone("JST", 9*60*60)),
+	}
+	for _, date := range sameDay {
+		if DailySeed(date) != seed {
+			t.Errorf("expected %s to have the seed of %s", date, day)
+		}
+	}
+
+	for _, date := range []time.Time{day.Add(-time.Minute), day.AddDate(0, 0, 1), day.AddDate(1, 0, 0)} {
+		if DailySeed(date) == seed {
+			t.Errorf("expected %s to have a different seed to %s", date, day)
+		}
+	}
+
+	// The seed decides the puzzle, so everyone gets the same puzzle of each
+	// size on the same day and a different one the next day
+	gm := newTestGameManager(t)
+	for _, size := range []int{4, 6} {
+		puzzles := []string{}
+		for _, date := range []time.Time{day, sameDay[1], day.AddDate(0, 0, 1)} {
+			game, err := gm.GenerateBoard(context.Background(), size, size, DailyDifficulty, ClassicVariant, DailySeed(date))
+			if err != nil {
+				t.Fatal(err)
+			}
+			puzzles = append(puzzles, FormatBoard(game.Board))
+		}
+
+		if puzzles[0] != puzzles[1] {
+			t.Errorf("size %d: expected the same puzzle on the same day", size)
+		}
+		if puzzles[0] == puzzles[2] {
+			t.Errorf("size %d: expected a different puzzle the next day", size)
+		}
+	}
+}
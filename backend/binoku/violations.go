@@ -0,0 +1,172 @@
+package binoku
+
+import (
+	"slices"
+	"web_games/utils"
+)
+
+// findViolations finds every rule broken on the board. Empty spaces
+// never break a rule
+func findViolations(board [][]GamePiece) []Violation {
+	violations := []Violation{}
+	violations = append(violations, findLineViolations(board, RowLine)...)
+	violations = append(violations, findLineViolations(utils.TransposeMatrix(board), ColumnLine)...)
+
+	return violations
+}
+
+// findConstraintViolations finds every constraint broken on the board
+func findConstraintViolations(board [][]GamePiece, constraints []Constraint) []Violation {
+	violations := []Violation{}
+	for _, constraint := range constraints {
+		if validateConstraint(board, constraint) {
+			continue
+		}
+
+		// Both cells are always on the same row or column
+		first := constraint.Cells[0]
+		line := Line{Type: RowLine, Index: first.Row}
+		if first.Col == constraint.Cells[1].Col {
+			line = Line{Type: ColumnLine, Index: first.Col}
+		}
+
+		violations = append(violations, Violation{
+			Rule:  ConstraintViolation,
+			Cells: constraint.Cells[:],
+			Lines: []Line{line},
+		})
+	}
+
+	return violations
+}
+
+// findLineViolations finds every rule broken by lines, which are either
+// all of the rows or all of the columns of a board
+func findLineViolations(lines [][]GamePiece, lineType LineType) []Violation {
+	violations := []Violation{}
+
+	for lineIndex, line := range lines {
+		// 1. There must be an equal number of 1's and 0's in each row/column
+		if !validateRuleOne(line) {
+			excess := GamePiece(0)
+			if countValue(line, 1) > len(line)/2 {
+				excess = 1
+			}
+
+			violations = append(violations, Violation{
+				Rule:  ImbalanceViolation,
+				Cells: lineCells(lineType, lineIndex, indicesOf(line, excess)),
+				Lines: []Line{{Type: lineType, Index: lineIndex}},
+			})
+		}
+
+		// 2. There cannot be more than 2 consecutive values next to each other
+		for _, run := range findTriples(line) {
+			violations = append(violations, Violation{
+				Rule:  TripleViolation,
+				Cells: lineCells(lineType, lineIndex, run),
+				Lines: []Line{{Type: lineType, Index: lineIndex}},
+			})
+		}
+
+		// 3. There cannot be any identical rows or any identical columns.
+		// Only check later lines so each pair is reported once
+		if slices.Contains(line, Empty) {
+			continue
+		}
+		for otherIndex := lineIndex + 1; otherIndex < len(lines); otherIndex++ {
+			if !slices.Equal(line, lines[otherIndex]) {
+				continue
+			}
+
+			allIndices := make([]int, len(line))
+			for i := range allIndices {
+				allIndices[i] = i
+			}
+
+			violations = append(violations, Violation{
+				Rule: DuplicateViolation,
+				Cells: append(
+					lineCells(lineType, lineIndex, allIndices),
+					lineCells(lineType, otherIndex, allIndices)...,
+				),
+				Lines: []Line{
+					{Type: lineType, Index: lineIndex},
+					{Type: lineType, Index: otherIndex},
+				},
+			})
+		}
+	}
+
+	return violations
+}
+
+// findTriples finds every run of 3 or more of the same value in a line,
+// returning the indices of each run
+func findTriples(line []GamePiece) [][]int {
+	runs := [][]int{}
+
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[start] {
+			continue
+		}
+
+		if line[start] != Empty && i-start >= 3 {
+			run := []int{}
+			for j := start; j < i; j++ {
+				run = append(run, j)
+			}
+			runs = append(runs, run)
+		}
+		start = i
+	}
+
+	return runs
+}
+
+// countValue counts how many times value is in a line
+func countValue(line []GamePiece, value GamePiece) int {
+	return len(indicesOf(line, value))
+}
+
+// indicesOf finds the indices of every instance of value in a line
+func indicesOf(line []GamePiece, value GamePiece) []int {
+	indices := []int{}
+	for i, item := range line {
+		if item == value {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// lineCells converts indices within a line to board coordinates
+func lineCells(lineType LineType, lineIndex int, indices []int) []Coordinate {
+	return utils.Map(indices, func(index int) Coordinate {
+		if lineType == ColumnLine {
+			return Coordinate{Col: lineIndex, Row: index}
+		}
+
+		return Coordinate{Col: index, Row: lineIndex}
+	})
+}
+
+// NewInvalidBoardHint summarises violations as the rows and columns
+// that contain a mistake
+func NewInvalidBoardHint(violations []Violation) InvalidBoardHint {
+	hint := InvalidBoardHint{Rows: []int{}, Cols: []int{}}
+	for _, violation := range violations {
+		for _, line := range violation.Lines {
+			if line.Type == RowLine && !slices.Contains(hint.Rows, line.Index) {
+				hint.Rows = append(hint.Rows, line.Index)
+			}
+			if line.Type == ColumnLine && !slices.Contains(hint.Cols, line.Index) {
+				hint.Cols = append(hint.Cols, line.Index)
+			}
+		}
+	}
+
+	return hint
+}
@@ -0,0 +1,146 @@
+package binoku
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindLineViolations(t *testing.T) {
+	row := func(index int) Line { return Line{Type: RowLine, Index: index} }
+	cells := func(row int, cols ...int) []Coordinate {
+		return lineCells(RowLine, row, cols)
+	}
+
+	tests := []struct {
+		name     string
+		lines    [][]GamePiece
+		lineType LineType
+		expected []Violation
+	}{
+		{
+			name:     "valid",
+			lines:    [][]GamePiece{{0, 1, 0, 1}, {1, 0, 1, 0}},
+			lineType: RowLine,
+			expected: []Violation{},
+		},
+		{
+			name:     "excess ones",
+			lines:    [][]GamePiece{{1, 1, 0, 1}},
+			lineType: RowLine,
+			expected: []Violation{{Rule: ImbalanceViolation, Cells: cells(0, 0, 1, 3), Lines: []Line{row(0)}}},
+		},
+		{
+			name:     "excess zeros with empties",
+			lines:    [][]GamePiece{{0, 1, 1, 0}, {0, Empty, 0, 0}},
+			lineType: RowLine,
+			expected: []Violation{{Rule: ImbalanceViolation, Cells: cells(1, 0, 2, 3), Lines: []Line{row(1)}}},
+		},
+		{
+			// Half of a line can be one value while the rest is still empty
+			name:     "half of one value",
+			lines:    [][]GamePiece{{1, 1, Empty, Empty}},
+			lineType: RowLine,
+			expected: []Violation{},
+		},
+		{
+			name:     "run of three",
+			lines:    [][]GamePiece{{0, 1, 1, 1, 0, 0}},
+			lineType: RowLine,
+			expected: []Violation{{Rule: TripleViolation, Cells: cells(0, 1, 2, 3), Lines: []Line{row(0)}}},
+		},
+		{
+			name:     "run of four",
+			lines:    [][]GamePiece{{1, 0, 0, 0, 0, 1, 1, Empty}},
+			lineType: RowLine,
+			expected: []Violation{{Rule: TripleViolation, Cells: cells(0, 1, 2, 3, 4), Lines: []Line{row(0)}}},
+		},
+		{
+			name:     "two runs",
+			lines:    [][]GamePiece{{0, 0, 0, 1, 1, 1}},
+			lineType: RowLine,
+			expected: []Violation{
+				{Rule: TripleViolation, Cells: cells(0, 0, 1, 2), Lines: []Line{row(0)}},
+				{Rule: TripleViolation, Cells: cells(0, 3, 4, 5), Lines: []Line{row(0)}},
+			},
+		},
+		{
+			name:     "empty run",
+			lines:    [][]GamePiece{{Empty, Empty, Empty, 0}},
+			lineType: RowLine,
+			expected: []Violation{},
+		},
+		{
+			name:     "duplicate rows",
+			lines:    [][]GamePiece{{0, 1, 0, 1}, {1, 0, 1, 0}, {0, 1, 0, 1}},
+			lineType: RowLine,
+			expected: []Violation{{
+				Rule:  DuplicateViolation,
+				Cells: append(cells(0, 0, 1, 2, 3), cells(2, 0, 1, 2, 3)...),
+				Lines: []Line{row(0), row(2)},
+			}},
+		},
+		{
+			// Lines with empties could still be completed differently
+			name:     "duplicates with empties",
+			lines:    [][]GamePiece{{0, 1, Empty, 1}, {0, 1, Empty, 1}},
+			lineType: RowLine,
+			expected: []Violation{},
+		},
+		{
+			name:     "duplicate columns",
+			lines:    [][]GamePiece{{1, 1, 0, 0}, {1, 0, 1, 0}, {1, 1, 0, 0}},
+			lineType: ColumnLine,
+			expected: []Violation{{
+				Rule:  DuplicateViolation,
+				Cells: append(lineCells(ColumnLine, 0, []int{0, 1, 2, 3}), lineCells(ColumnLine, 2, []int{0, 1, 2, 3})...),
+				Lines: []Line{{Type: ColumnLine, Index: 0}, {Type: ColumnLine, Index: 2}},
+			}},
+		},
+		{
+			name:     "imbalanced run in a column",
+			lines:    [][]GamePiece{{1, 1, 1, 0}},
+			lineType: ColumnLine,
+			expected: []Violation{
+				{
+					Rule:  ImbalanceViolation,
+					Cells: []Coordinate{{Col: 0, Row: 0}, {Col: 0, Row: 1}, {Col: 0, Row: 2}},
+					Lines: []Line{{Type: ColumnLine, Index: 0}},
+				},
+				{
+					Rule:  TripleViolation,
+					Cells: []Coordinate{{Col: 0, Row: 0}, {Col: 0, Row: 1}, {Col: 0, Row: 2}},
+					Lines: []Line{{Type: ColumnLine, Index: 0}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations := findLineViolations(test.lines, test.lineType)
+			if !reflect.DeepEqual(violations, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, violations)
+			}
+		})
+	}
+}
+
+func TestNewInvalidBoardHint(t *testing.T) {
+	hint := NewInvalidBoardHint([]Violation{})
+	if len(hint.Rows) != 0 || len(hint.Cols) != 0 || hint.Rows == nil || hint.Cols == nil {
+		t.Errorf("expected no rows or columns, got %+v", hint)
+	}
+
+	// Each line is only reported once, however many violations it has
+	hint = NewInvalidBoardHint([]Violation{
+		{Rule: ImbalanceViolation, Lines: []Line{{Type: RowLine, Index: 1}}},
+		{Rule: TripleViolation, Lines: []Line{{Type: RowLine, Index: 1}}},
+		{Rule: DuplicateViolation, Lines: []Line{{Type: RowLine, Index: 0}, {Type: RowLine, Index: 1}}},
+		{Rule: TripleViolation, Lines: []Line{{Type: ColumnLine, Index: 3}}},
+		{Rule: ConstraintViolation, Lines: []Line{{Type: ColumnLine, Index: 3}}},
+	})
+	expected := InvalidBoardHint{Rows: []int{1, 0}, Cols: []int{3}}
+	if !reflect.DeepEqual(hint, expected) {
+		t.Errorf("expected %+v, got %+v", expected, hint)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"web_games/binoku"
+)
+
+// runGenerate generates puzzles, writing them to stdout or to a file
+// each in a directory
+func runGenerate(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	puzzle := addPuzzleFlags(flags, 1)
+	format := flags.String("format", "text", "text, json for a game per line, or booklet for a printable HTML booklet")
+	out := flags.String("out", "", "directory to write each puzzle to as <puzzle ID>.txt, or the booklet to booklet.html, instead of stdout")
+	flags.Parse(args)
+
+	options, err := puzzle.options()
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" && *format != "booklet" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	// The text format has no way of writing constraints, and booklets
+	// don't draw them
+	if *format != "json" && options.variant != binoku.ClassicVariant {
+		return fmt.Errorf("only classic puzzles can be written in the %s format, use -format json", *format)
+	}
+	if *out != "" {
+		err = os.MkdirAll(*out, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	gm, err := newGameManager(*puzzle.timeout)
+	if err != nil {
+		return err
+	}
+
+	// Booklets have every puzzle in one document, so they are written once
+	// every puzzle has been generated
+	var booklet []binoku.Game
+	for i := range *puzzle.count {
+		game, err := gm.GenerateBoard(
+			context.Background(),
+			options.rows,
+			options.cols,
+			options.difficulty,
+			options.variant,
+			puzzle.seedFor(i),
+		)
+		if err != nil {
+			return err
+		}
+
+		if *format == "booklet" {
+			booklet = append(booklet, game)
+			continue
+		}
+		if *out == "" {
+			if *format == "text" && i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			err = writeGame(stdout, game, *format)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		extension := ".txt"
+		if *format == "json" {
+			extension = ".json"
+		}
+		file, err := os.Create(filepath.Join(*out, game.PuzzleID+extension))
+		if err != nil {
+			return err
+		}
+		err = writeGame(file, game, *format)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if *format == "booklet" {
+		return writeBooklet(booklet, options, *out, stdout)
+	}
+
+	return nil
+}
+
+// writeBooklet writes games as a booklet to stdout, or to booklet.html in
+// the out directory
+func writeBooklet(games []binoku.Game, options puzzleOptions, out string, stdout io.Writer) error {
+	title := fmt.Sprintf("Binoku %dx%d %s puzzles", options.rows, options.cols, options.difficulty)
+	if out == "" {
+		return binoku.RenderBooklet(stdout, title, games)
+	}
+
+	file, err := os.Create(filepath.Join(out, "booklet.html"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return binoku.RenderBooklet(file, title, games)
+}
+
+// writeGame writes a single game in the format
+func writeGame(w io.Writer, game binoku.Game, format string) error {
+	if format == "json" {
+		// The state is only useful to the server that encrypted it
+		game.EncryptedState = ""
+		return json.NewEncoder(w).Encode(game)
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"# %s %s, score %d (%s)\n%s",
+		game.PuzzleID,
+		game.Difficulty,
+		game.DifficultyScore,
+		game.HardestTechnique,
+		binoku.FormatBoard(game.Board),
+	)
+	return err
+}
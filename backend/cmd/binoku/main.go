@@ -0,0 +1,145 @@
+// Command binoku generates, solves and measures Binoku puzzles without
+// running the server.
+//
+// Usage:
+//
+//	binoku generate [flags]
+//	binoku solve [flags] [file ...]
+//	binoku stats [flags]
+//
+// Run "binoku <command> -h" for each command's flags.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"web_games/binoku"
+	"web_games/services"
+	"web_games/utils"
+)
+
+// commands are the subcommands, by name. Each writes its output to
+// stdout
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"generate": runGenerate,
+	"solve":    runSolve,
+	"stats":    runStats,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	err := run(os.Args[2:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: binoku <generate|solve|stats> [flags]")
+}
+
+// newGameManager creates a GameManager for the command line. The pool is
+// disabled, since every puzzle is only generated once
+func newGameManager(timeout time.Duration) (binoku.GameManager, error) {
+	key, err := utils.GenerateGCMKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return binoku.NewGameManager(
+		context.Background(),
+		utils.BinokuConfig{MaxGenerationTime: timeout},
+		services.NewEncryption(key),
+	), nil
+}
+
+// puzzleFlags are the flags shared by the commands that generate puzzles
+type puzzleFlags struct {
+	size       *int
+	rows       *int
+	cols       *int
+	difficulty *string
+	variant    *string
+	seed       *int64
+	count      *int
+	timeout    *time.Duration
+}
+
+// addPuzzleFlags adds the flags for generating puzzles to a command
+func addPuzzleFlags(flags *flag.FlagSet, defaultCount int) puzzleFlags {
+	return puzzleFlags{
+		size:       flags.Int("size", 6, "width and height of square boards"),
+		rows:       flags.Int("rows", 0, "number of rows, overriding size"),
+		cols:       flags.Int("cols", 0, "number of columns, overriding size"),
+		difficulty: flags.String("difficulty", string(binoku.MediumDifficulty), "easy, medium or hard"),
+		variant:    flags.String("variant", string(binoku.ClassicVariant), "classic or plus"),
+		seed:       flags.Int64("seed", -1, "seed of the first puzzle, each puzzle after uses the next seed. Random if negative"),
+		count:      flags.Int("count", defaultCount, "number of puzzles to generate"),
+		timeout:    flags.Duration("timeout", 0, "longest a single puzzle may take to generate, 0 for no limit"),
+	}
+}
+
+// puzzleOptions are the validated options for generating puzzles
+type puzzleOptions struct {
+	rows       int
+	cols       int
+	difficulty binoku.Difficulty
+	variant    binoku.Variant
+}
+
+// options validates the flags
+func (f puzzleFlags) options() (puzzleOptions, error) {
+	options := puzzleOptions{
+		rows:       *f.size,
+		cols:       *f.size,
+		difficulty: binoku.Difficulty(*f.difficulty),
+		variant:    binoku.Variant(*f.variant),
+	}
+	if *f.rows != 0 {
+		options.rows = *f.rows
+	}
+	if *f.cols != 0 {
+		options.cols = *f.cols
+	}
+
+	isValid, message := binoku.ValidateDimensions(options.rows, options.cols)
+	if !isValid {
+		return puzzleOptions{}, fmt.Errorf("invalid dimensions: %s", message)
+	}
+	if !options.difficulty.IsValid() {
+		return puzzleOptions{}, fmt.Errorf("unknown difficulty %q", options.difficulty)
+	}
+	if !options.variant.IsValid() {
+		return puzzleOptions{}, fmt.Errorf("unknown variant %q", options.variant)
+	}
+	if *f.count < 1 {
+		return puzzleOptions{}, fmt.Errorf("count must be at least 1")
+	}
+
+	return options, nil
+}
+
+// seedFor gets the seed of the i'th puzzle
+func (f puzzleFlags) seedFor(i int) int64 {
+	if *f.seed < 0 {
+		return binoku.NewSeed()
+	}
+
+	return *f.seed + int64(i)
+}
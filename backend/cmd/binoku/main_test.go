@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"web_games/binoku"
+)
+
+// puzzlesDir has the puzzles and expected solutions the binoku package
+// tests its solver with
+var puzzlesDir = filepath.Join("..", "..", "binoku", "testdata", "puzzles")
+
+// TestSolveGolden solves every test puzzle and compares the output with
+// the puzzle's .golden file, which has the same solutions without the
+// puzzle's path
+func TestSolveGolden(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(puzzlesDir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no puzzles found")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		t.Run(name, func(t *testing.T) {
+			golden, err := os.ReadFile(strings.TrimSuffix(path, ".txt") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, solutions, _ := strings.Cut(string(golden), "\n")
+			expected := "# " + path + ": " + strings.TrimPrefix(header, "# ") + "\n" + strings.TrimPrefix(solutions, "\n")
+
+			var out strings.Builder
+			err = runSolve([]string{path}, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+			}
+		})
+	}
+}
+
+func TestSolveMultipleFiles(t *testing.T) {
+	paths := []string{filepath.Join(puzzlesDir, "easy-4x4.txt"), filepath.Join(puzzlesDir, "medium-6x6.txt")}
+
+	var out strings.Builder
+	err := runSolve(paths, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		if !strings.Contains(out.String(), "# "+path+": "+string(binoku.Solved)) {
+			t.Errorf("expected %s to be solved, got:\n%s", path, out.String())
+		}
+	}
+
+	// The other files are still solved when one can't be read
+	out.Reset()
+	err = runSolve([]string{filepath.Join(puzzlesDir, "missing.txt"), paths[0]}, &out)
+	if err == nil || !strings.Contains(out.String(), "# "+paths[0]) {
+		t.Errorf("expected an error after solving %s, got %v:\n%s", paths[0], err, out.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	args := []string{"-size", "6", "-difficulty", "easy", "-seed", "1", "-count", "2"}
+
+	var out strings.Builder
+	err := runGenerate(args, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each puzzle is written with its ID, and the seeds follow on
+	puzzles := strings.Split(out.String(), "\n\n")
+	if len(puzzles) != 2 {
+		t.Fatalf("expected 2 puzzles, got:\n%s", out.String())
+	}
+	for i, puzzle := range puzzles {
+		header, text, _ := strings.Cut(puzzle, "\n")
+		id := binoku.NewPuzzleID(6, 6, binoku.EasyDifficulty, binoku.ClassicVariant, int64(i+1))
+		if !strings.HasPrefix(header, "# "+id+" easy") {
+			t.Errorf("expected puzzle %s, got %q", id, header)
+		}
+		if _, err := binoku.ParseBoard(text); err != nil {
+			t.Errorf("puzzle %s can't be parsed: %v", id, err)
+		}
+	}
+
+	// The same seed always generates the same puzzles
+	var again strings.Builder
+	err = runGenerate(args, &again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != out.String() {
+		t.Error("expected the same seed to generate the same puzzles")
+	}
+}
+
+func TestStats(t *testing.T) {
+	var out strings.Builder
+	err := runStats([]string{"-size", "4", "-difficulty", "easy", "-seed", "1", "-count", "3"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "3 4x4 easy classic puzzles") {
+		t.Errorf("unexpected stats:\n%s", out.String())
+	}
+}
+
+func TestPuzzleFlagValidation(t *testing.T) {
+	tests := map[string][]string{
+		"odd size":           {"-size", "5"},
+		"size too small":     {"-size", "2"},
+		"size too large":     {"-size", "16"},
+		"columns too large":  {"-rows", "4", "-cols", "16"},
+		"no count":           {"-count", "0"},
+		"negative count":     {"-count", "-1"},
+		"unknown difficulty": {"-difficulty", "expert"},
+		"unknown variant":    {"-variant", "minus"},
+	}
+
+	commands := map[string]func(args []string, stdout *strings.Builder) error{
+		"generate": func(args []string, stdout *strings.Builder) error { return runGenerate(args, stdout) },
+		"stats":    func(args []string, stdout *strings.Builder) error { return runStats(args, stdout) },
+	}
+	for command, run := range commands {
+		for name, args := range tests {
+			t.Run(command+" "+name, func(t *testing.T) {
+				var out strings.Builder
+				err := run(args, &out)
+				if err == nil || out.Len() > 0 {
+					t.Errorf("expected %v to be rejected, got %v:\n%s", args, err, out.String())
+				}
+			})
+		}
+	}
+
+	// Only the json format can write constraints
+	err := runGenerate([]string{"-variant", "plus"}, &strings.Builder{})
+	if err == nil {
+		t.Error("expected plus puzzles to be rejected in the text format")
+	}
+	err = runGenerate([]string{"-format", "pdf"}, &strings.Builder{})
+	if err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"web_games/binoku"
+)
+
+// runSolve solves puzzles in the text format from files, or stdin if
+// there are no files
+func runSolve(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 0, "longest a single puzzle may take to solve, 0 for no limit")
+	flags.Parse(args)
+
+	gm, err := newGameManager(*timeout)
+	if err != nil {
+		return err
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	// Keep solving the other files if one fails, reporting it at the end
+	failed := 0
+	for i, path := range paths {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		err = solveFile(gm, path, stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d puzzles could not be solved", failed, len(paths))
+	}
+
+	return nil
+}
+
+// solveFile solves the puzzle in a file and writes its solutions to
+// stdout. A path of - reads from stdin
+func solveFile(gm binoku.GameManager, path string, stdout io.Writer) error {
+	var text []byte
+	var err error
+	if path == "-" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	board, err := binoku.ParseBoard(string(text))
+	if err != nil {
+		return err
+	}
+	isValid, message := binoku.ValidateDimensions(len(board), len(board[0]))
+	if !isValid {
+		return errors.New(message)
+	}
+
+	result, err := gm.Solve(context.Background(), board, nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "# %s: %s\n", path, result.Status)
+	for i, solution := range result.Solutions {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprint(stdout, binoku.FormatBoard(solution))
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+	"web_games/binoku"
+)
+
+// runStats generates puzzles and writes how long they took and how hard
+// they were to stdout
+func runStats(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	puzzle := addPuzzleFlags(flags, 20)
+	flags.Parse(args)
+
+	options, err := puzzle.options()
+	if err != nil {
+		return err
+	}
+
+	gm, err := newGameManager(*puzzle.timeout)
+	if err != nil {
+		return err
+	}
+
+	count := *puzzle.count
+	durations := make([]time.Duration, 0, count)
+	givens := make([]int, 0, count)
+	scores := make([]int, 0, count)
+	techniques := map[binoku.Technique]int{}
+	for i := range count {
+		start := time.Now()
+		game, err := gm.GenerateBoard(
+			context.Background(),
+			options.rows,
+			options.cols,
+			options.difficulty,
+			options.variant,
+			puzzle.seedFor(i),
+		)
+		if err != nil {
+			return err
+		}
+
+		durations = append(durations, time.Since(start))
+		givens = append(givens, countGivens(game.Board))
+		scores = append(scores, game.DifficultyScore)
+		techniques[game.HardestTechnique]++
+	}
+
+	fmt.Fprintf(
+		stdout,
+		"%d %dx%d %s %s puzzles\n\n",
+		count,
+		options.rows,
+		options.cols,
+		options.difficulty,
+		options.variant,
+	)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tmin\tmean\tmax")
+	fmt.Fprintf(
+		w,
+		"time\t%v\t%v\t%v\n",
+		slices.Min(durations).Round(time.Microsecond),
+		(sum(durations) / time.Duration(count)).Round(time.Microsecond),
+		slices.Max(durations).Round(time.Microsecond),
+	)
+	fmt.Fprintf(w, "givens\t%d\t%.1f\t%d\n", slices.Min(givens), mean(givens), slices.Max(givens))
+	fmt.Fprintf(w, "score\t%d\t%.1f\t%d\n", slices.Min(scores), mean(scores), slices.Max(scores))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "hardest technique\tpuzzles")
+	for _, technique := range binoku.Techniques() {
+		if techniques[technique] > 0 {
+			fmt.Fprintf(w, "%s\t%d\n", technique, techniques[technique])
+		}
+	}
+
+	return w.Flush()
+}
+
+// countGivens counts the filled cells of a puzzle
+func countGivens(board [][]binoku.GamePiece) int {
+	givens := 0
+	for _, row := range board {
+		for _, cell := range row {
+			if cell != binoku.Empty {
+				givens++
+			}
+		}
+	}
+
+	return givens
+}
+
+func sum[T int | time.Duration](values []T) T {
+	var total T
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
+
+func mean(values []int) float64 {
+	return float64(sum(values)) / float64(len(values))
+}
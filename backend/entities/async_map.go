@@ -49,3 +49,25 @@ func (m AsyncMap[K, T]) Size() int {
 	defer m.lock.RUnlock()
 	return len(m.data)
 }
+
+// DeleteFunc deletes every item that f returns true for
+func (m AsyncMap[K, T]) DeleteFunc(f func(key K, item T) bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	maps.DeleteFunc(m.data, f)
+}
+
+// CountFunc counts the items that f returns true for
+func (m AsyncMap[K, T]) CountFunc(f func(key K, item T) bool) int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	count := 0
+	for key, item := range m.data {
+		if f(key, item) {
+			count++
+		}
+	}
+
+	return count
+}
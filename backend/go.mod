@@ -8,6 +8,6 @@ require (
 )
 
 require (
-	github.com/coder/websocket v1.8.13 // indirect
+	github.com/coder/websocket v1.8.13
 	github.com/google/uuid v1.6.0
 )
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -49,8 +50,10 @@ func main() {
 	}
 
 	container := DependencyContainer{
-		BinokuController: binoku.NewGameManager(),
+		// The controllers' background work runs for as long as the server
+		BinokuController: binoku.NewGameManager(context.Background(), config.Binoku, encryptionService),
 		WordLadderController: wordchain.NewController(
+			context.Background(),
 			config.WordLadder.MaxServers,
 			config.WordLadder.MaxPlayersPerServer,
 			wordChainDictionary,
@@ -80,14 +83,87 @@ func main() {
 		http.MethodGet,
 		binokuHandler.NewGame,
 	)
+	handleService.Handle(
+		"/binoku/puzzle/{id}",
+		http.MethodGet,
+		binokuHandler.Puzzle,
+	)
+	handleService.Handle(
+		"/binoku/daily",
+		http.MethodGet,
+		binokuHandler.Daily,
+	)
 	handleService.Handle(
 		"/binoku/validate-game",
 		http.MethodPost,
 		binokuHandler.ValidateBoard,
 	)
+	handleService.Handle(
+		"/binoku/check",
+		http.MethodPost,
+		binokuHandler.CheckBoard,
+	)
+	handleService.Handle(
+		"/binoku/solve",
+		http.MethodPost,
+		binokuHandler.Solve,
+	)
+	handleService.Handle(
+		"/binoku/hint",
+		http.MethodPost,
+		binokuHandler.Hint,
+	)
+	handleService.Handle(
+		"/binoku/import",
+		http.MethodPost,
+		binokuHandler.Import,
+	)
+	handleService.Handle(
+		"/binoku/import/solve",
+		http.MethodPost,
+		binokuHandler.ImportSolve,
+	)
+	handleService.Handle(
+		"/binoku/render",
+		http.MethodPost,
+		binokuHandler.Render,
+	)
+	handleService.Handle(
+		"/binoku/booklet",
+		http.MethodGet,
+		binokuHandler.Booklet,
+	)
+	handleService.Handle(
+		"/binoku/session",
+		http.MethodPost,
+		binokuHandler.StartSession,
+	)
+	handleService.Handle(
+		"/binoku/session/{id}/move",
+		http.MethodPost,
+		binokuHandler.SessionMove,
+	)
+	handleService.Handle(
+		"/binoku/session/{id}/undo",
+		http.MethodPost,
+		binokuHandler.SessionUndo,
+	)
+	handleService.Handle(
+		"/binoku/session/{id}/redo",
+		http.MethodPost,
+		binokuHandler.SessionRedo,
+	)
+	handleService.Handle(
+		"/binoku/session/{id}/replay",
+		http.MethodGet,
+		binokuHandler.SessionReplay,
+	)
 
 	// Word Ladder
-	wordLadderHandler := wordchain.NewHandler(container.WordLadderController)
+	wordLadderHandler := wordchain.NewHandler(
+		container.WordLadderController,
+		[]string{config.FrontendDomain},
+	)
 	handleService.Handle(
 		"/word-chain/new-game",
 		http.MethodGet,
@@ -98,6 +174,47 @@ func main() {
 		http.MethodPost,
 		wordLadderHandler.ValidateAnswer,
 	)
+	handleService.Handle(
+		"/word-chain/lobby",
+		http.MethodPost,
+		wordLadderHandler.CreateLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}",
+		http.MethodGet,
+		wordLadderHandler.GetLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/join",
+		http.MethodPost,
+		wordLadderHandler.JoinLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/leave",
+		http.MethodPost,
+		wordLadderHandler.LeaveLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/start",
+		http.MethodPost,
+		wordLadderHandler.StartLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/guess",
+		http.MethodPost,
+		wordLadderHandler.GuessLobby,
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/events",
+		http.MethodGet,
+		wordLadderHandler.LobbyEvents,
+		middleware.NewSSEMiddleware(),
+	)
+	handleService.Handle(
+		"/word-chain/lobby/{code}/ws",
+		http.MethodGet,
+		wordLadderHandler.LobbySocket,
+	)
 
 	port := config.Port
 	listenAddr := fmt.Sprintf(":%d", port)
@@ -30,6 +30,10 @@ func NewEncryption(key cipher.AEAD) Encryption {
 // Encrypt encrypts the plaintext to a hex string
 func (e *encryption) Encrypt(data any) (string, error) {
 	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
 	nonce, err := e.generateNonce()
 	if err != nil {
 		return "", err
@@ -46,6 +50,10 @@ func (e *encryption) Decrypt(ciphertext string, obj any) error {
 		return errors.Wrap(err, "error decoding cipher hex")
 	}
 
+	if len(bytes) < e.gcm.NonceSize() {
+		return errors.New("ciphertext is too short")
+	}
+
 	nonce := bytes[:e.gcm.NonceSize()]
 	encryptedData := bytes[e.gcm.NonceSize():]
 
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,9 +27,24 @@ type Config struct {
 	FullCertPath   string                `yaml:"fullCertPath"`
 	PrivateKeyPath string                `yaml:"privateKeyPath"`
 
+	Binoku     BinokuConfig     `yaml:"binoku"`
 	WordLadder WordLadderConfig `yaml:"wordLadder"`
 }
 
+// BinokuConfig is the configuration for the Binoku game
+type BinokuConfig struct {
+	// PoolSize is the number of pre-generated puzzles to keep for each
+	// board size and difficulty. 0 disables the pool
+	PoolSize int `yaml:"poolSize"`
+	// PoolWorkers is the number of goroutines generating puzzles for the pool
+	PoolWorkers int `yaml:"poolWorkers"`
+	// PoolBoardSizes are the board sizes to pre-generate puzzles for
+	PoolBoardSizes []int `yaml:"poolBoardSizes"`
+	// MaxGenerationTime is the longest generating or solving a single
+	// board may take, e.g. "5s". 0 means no limit
+	MaxGenerationTime time.Duration `yaml:"maxGenerationTime"`
+}
+
 // WordLadderConfig is the configuration for the WordLadder game
 type WordLadderConfig struct {
 	// MaxServers is the maxmium number of servers (concurrent games)
@@ -21,6 +21,16 @@ func ShuffleSlice[T any](slice []T) []T {
 	return slice
 }
 
+// ShuffleSliceWithRand takes in a slice and returns the slice
+// shuffled using the provided source of randomness
+func ShuffleSliceWithRand[T any](rng *rand.Rand, slice []T) []T {
+	rng.Shuffle(len(slice), func(i, j int) {
+		slice[i], slice[j] = slice[j], slice[i]
+	})
+
+	return slice
+}
+
 // SumSlice sums the values in a slice of integers
 func SumSlice(slice []int) int {
 	total := 0
@@ -43,23 +53,22 @@ func DuplicateMatrix[T any](matrix [][]T) [][]T {
 	return dupe
 }
 
-// TransposeMatrix rotates the matrix 90 degrees clockwise
+// TransposeMatrix flips the matrix over its diagonal, so that the
+// columns of the matrix become the rows of the result. The matrix does
+// not need to be square, but every row must be the same length
 func TransposeMatrix[T any](matrix [][]T) [][]T {
-	transposed := DuplicateMatrix(matrix)
-	n := len(transposed)
 	// Handle the empty matrix case.
-	if n == 0 {
+	if len(matrix) == 0 {
 		return matrix
 	}
 
-	for _, row := range transposed {
-		slices.Reverse(row)
-	}
-
-	// For every element in the matrix, assign it to the transposed position.
-	for i := range n {
-		for j := i + 1; j < n; j++ {
-			transposed[i][j], transposed[j][i] = transposed[j][i], transposed[i][j]
+	rows, cols := len(matrix), len(matrix[0])
+	transposed := make([][]T, cols)
+	for i := range cols {
+		transposed[i] = make([]T, rows)
+		// For every element in the column, assign it to the transposed position.
+		for j := range rows {
+			transposed[i][j] = matrix[j][i]
 		}
 	}
 
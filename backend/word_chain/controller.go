@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"web_games/entities"
 	"web_games/services"
 	"web_games/utils"
@@ -13,46 +14,77 @@ import (
 // ErrInvalidPosition is returned when a player submits an invalid position
 var ErrInvalidPosition = errors.New("Invalid position")
 
-// TargetChainLength is the targeted length of each chain
-// TODO - make this a game option rather than hard-coded
-const TargetChainLength = 7
+const (
+	// DefaultChainLength is the length of a chain when none is chosen
+	DefaultChainLength = 7
+	// MinChainLength is the shortest chain that can be chosen
+	MinChainLength = 3
+	// MaxChainLength is the longest chain that can be chosen
+	MaxChainLength = 10
+)
 
 // Controller represents a WordLadder controller
 type Controller interface {
-	CreateGame(ctx context.Context) (Game, error)
+	CreateGame(ctx context.Context, options GameOptions) (Game, error)
 	ValidateGuess(guess string, game Game) (bool, Game, error)
+	CreateMultiplayerLobby(ctx context.Context, name string, client string) (LobbyMembership, error)
+	JoinLobby(code string, name string) (LobbyMembership, error)
+	GetLobby(code string) (Lobby, error)
+	LeaveLobby(code string, playerID string) (Lobby, error)
+	StartLobby(ctx context.Context, code string, playerID string) (Lobby, error)
+	GuessLobby(code string, playerID string, guess string) (LobbyGuessResponse, error)
+	LobbyEvents(ctx context.Context, code string, lastEventID int) (<-chan LobbyEvent, error)
+	ConnectLobby(code string, playerID string) error
+	DisconnectLobby(code string, playerID string)
 }
 
 type controller struct {
-	dictionary   Dictionary
-	runningGames entities.AsyncMap[string, Game]
+	dictionary Dictionary
+	// generators generate the chains of each difficulty
+	generators map[Difficulty]chainGenerator
+	// runningGames are the multiplayer lobbies, by lobby code
+	runningGames entities.AsyncMap[string, *lobby]
+	// lobbyLock is held while creating a lobby, so that the number of
+	// lobbies can't go over maxServers
+	lobbyLock           *sync.Mutex
+	maxServers          int
+	maxPlayersPerServer int
 
 	encryption services.Encryption
 }
 
-// NewController creates a new word ladder Controller
+// NewController creates a new word ladder Controller. Expired lobbies
+// are removed in the background until ctx is done
 func NewController(
+	ctx context.Context,
 	maxServers int,
 	maxPlayersPerServer int,
 	dictionary Dictionary,
 	encryption services.Encryption,
 ) Controller {
-	return controller{
-		dictionary:   dictionary,
-		runningGames: entities.NewAsyncMap(map[string]Game{}),
-		encryption:   encryption,
+	c := controller{
+		dictionary:          dictionary,
+		generators:          newChainGenerators(dictionary),
+		runningGames:        entities.NewAsyncMap(map[string]*lobby{}),
+		lobbyLock:           &sync.Mutex{},
+		maxServers:          maxServers,
+		maxPlayersPerServer: maxPlayersPerServer,
+		encryption:          encryption,
 	}
-}
+	go c.pruneExpiredLobbies(ctx)
 
-func (c controller) CreateMultiplayerLobby(ctx context.Context) (Game, error) {
-	// TODO
-	return Game{}, errors.New("not implemented")
+	return c
 }
 
-func (c controller) CreateGame(ctx context.Context) (Game, error) {
-	chain := c.generateLadder(entities.NewSetStack[string]())
+func (c controller) CreateGame(ctx context.Context, options GameOptions) (Game, error) {
+	chain, err := c.generators[options.Difficulty].generate(ctx, options.Length)
+	if err != nil {
+		return Game{}, err
+	}
+
 	state := GameState{
-		GeneratedChain: chain.Slice(),
+		GameOptions:    options,
+		GeneratedChain: chain,
 		UserProgress:   1,
 	}
 	encryptedState, err := c.encryption.Encrypt(state)
@@ -83,35 +115,6 @@ func (c controller) generateLobbyCode() string {
 	return lobbyCode
 }
 
-// generate ladder generates a feasibly solvable start and end word
-// we love recursion here
-func (c controller) generateLadder(currentLadder entities.SetStack[string]) entities.SetStack[string] {
-	if currentLadder.Size() == 0 {
-		word := utils.GetRandomItem(utils.GetKeys(c.dictionary))
-		currentLadder.Push(word)
-	}
-
-	// Base case
-	if currentLadder.Size() == TargetChainLength {
-		return currentLadder
-	}
-
-	// Using the last word, find a word that works with the ladder
-	previousWord, _ := currentLadder.Peek()
-	possibleWords, ok := c.dictionary[previousWord]
-	// It's possible that the chosen word does not have any associated words
-	// If that happens, move on.
-	// TODO - it's possible that this could create an infinite loop, we should fix that
-	if !ok {
-		currentLadder.Pop()
-		return c.generateLadder(currentLadder)
-	}
-
-	nextWord := utils.GetRandomItem(possibleWords)
-	currentLadder.Push(nextWord)
-	return c.generateLadder(currentLadder)
-}
-
 func (c controller) ValidateGuess(guess string, game Game) (bool, Game, error) {
 	var state GameState
 	err := c.encryption.Decrypt(game.EncryptedState, &state)
@@ -119,14 +122,15 @@ func (c controller) ValidateGuess(guess string, game Game) (bool, Game, error) {
 		return false, game, err
 	}
 
+	if state.UserProgress < 1 || state.UserProgress >= len(state.GeneratedChain) {
+		return false, game, ErrInvalidPosition
+	}
 	if !strings.EqualFold(guess, state.GeneratedChain[state.UserProgress]) {
 		return false, game, nil
 	}
 
-	updatedData := GameState{
-		GeneratedChain: state.GeneratedChain,
-		UserProgress:   state.UserProgress + 1,
-	}
+	updatedData := state
+	updatedData.UserProgress++
 	encryptedData, err := c.encryption.Encrypt(updatedData)
 	if err != nil {
 		return false, game, err
@@ -0,0 +1,89 @@
+package wordchain
+
+import (
+	"cmp"
+	"slices"
+)
+
+// linkScores scores how hard each link in the dictionary is to guess, by
+// word and then by the word it links to. A link is harder the more words
+// the first word links to, since there are more to choose between, and
+// the fewer words link to the second word, since it is a less common way
+// to follow a word
+func linkScores(dictionary Dictionary) map[string]map[string]float64 {
+	// How many words link to each word
+	commonness := map[string]int{}
+	for _, nextWords := range dictionary {
+		for _, nextWord := range nextWords {
+			commonness[nextWord]++
+		}
+	}
+
+	scores := map[string]map[string]float64{}
+	for word, nextWords := range dictionary {
+		scores[word] = map[string]float64{}
+		for _, nextWord := range nextWords {
+			scores[word][nextWord] = float64(len(nextWords)) / float64(commonness[nextWord])
+		}
+	}
+
+	return scores
+}
+
+// difficultyLinks splits the dictionary's links by difficulty. The links
+// are ordered by their score and split into thirds, with the easiest third
+// for the easiest difficulty. Links with the same score are ordered by
+// their words, so that every third has links even if all the scores are
+// the same
+func difficultyLinks(dictionary Dictionary) map[Difficulty]Dictionary {
+	scores := linkScores(dictionary)
+
+	type link struct {
+		word     string
+		nextWord string
+	}
+	allLinks := []link{}
+	for word, nextWords := range dictionary {
+		for _, nextWord := range nextWords {
+			allLinks = append(allLinks, link{word, nextWord})
+		}
+	}
+	slices.SortFunc(allLinks, func(a, b link) int {
+		return cmp.Or(
+			cmp.Compare(scores[a.word][a.nextWord], scores[b.word][b.nextWord]),
+			cmp.Compare(a.word, b.word),
+			cmp.Compare(a.nextWord, b.nextWord),
+		)
+	})
+
+	links := map[Difficulty]Dictionary{}
+	for _, difficulty := range difficulties {
+		links[difficulty] = Dictionary{}
+	}
+	for i, link := range allLinks {
+		difficulty := difficulties[i*len(difficulties)/len(allLinks)]
+		links[difficulty][link.word] = append(links[difficulty][link.word], link.nextWord)
+	}
+
+	return links
+}
+
+// newDifficultyDictionaries splits the dictionary by difficulty. Each
+// difficulty's dictionary has the links of its third and every easier
+// third, so a chain of any difficulty can still use easy links
+func newDifficultyDictionaries(dictionary Dictionary) map[Difficulty]Dictionary {
+	links := difficultyLinks(dictionary)
+
+	dictionaries := map[Difficulty]Dictionary{}
+	for i, difficulty := range difficulties {
+		difficultyDictionary := Dictionary{}
+		for _, easier := range difficulties[:i+1] {
+			for word, nextWords := range links[easier] {
+				difficultyDictionary[word] = append(difficultyDictionary[word], nextWords...)
+			}
+		}
+		dictionaries[difficulty] = difficultyDictionary
+	}
+
+	return dictionaries
+}
@@ -0,0 +1,106 @@
+package wordchain
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"web_games/utils"
+)
+
+func readTestDictionary(t *testing.T) Dictionary {
+	dictionary, err := utils.ReadJSONFile[Dictionary]("../data/word_chain_dictionary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dictionary
+}
+
+func TestDifficultyDictionaries(t *testing.T) {
+	dictionary := readTestDictionary(t)
+	dictionaries := newDifficultyDictionaries(dictionary)
+	scores := linkScores(dictionary)
+
+	// Every link of a difficulty is also in the harder difficulties, and
+	// only the hardest difficulty has every link
+	for i, difficulty := range difficulties[:len(difficulties)-1] {
+		harder := dictionaries[difficulties[i+1]]
+		links := 0
+		for word, nextWords := range dictionaries[difficulty] {
+			for _, nextWord := range nextWords {
+				if !slices.Contains(harder[word], nextWord) {
+					t.Fatalf("%s link %s-%s missing from %s", difficulty, word, nextWord, difficulties[i+1])
+				}
+				links++
+			}
+		}
+		if links == 0 || links == countLinks(harder) {
+			t.Errorf("expected %s to have some but not all of the %s links, got %d", difficulty, difficulties[i+1], links)
+		}
+	}
+	if countLinks(dictionaries[HardDifficulty]) != countLinks(dictionary) {
+		t.Error("expected hard to have every link")
+	}
+
+	// The easy links are no harder than any other link
+	easiest, hardest := 0.0, 0.0
+	for word, nextWords := range dictionary {
+		for _, nextWord := range nextWords {
+			score := scores[word][nextWord]
+			if slices.Contains(dictionaries[EasyDifficulty][word], nextWord) {
+				easiest = max(easiest, score)
+			} else if hardest == 0 || score < hardest {
+				hardest = score
+			}
+		}
+	}
+	if easiest > hardest {
+		t.Errorf("expected easy links to score at most %f, got %f", hardest, easiest)
+	}
+}
+
+func TestCreateGameOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := NewController(ctx, 1, 2, readTestDictionary(t), newTestController(t, 1, 2).(controller).encryption)
+	generators := c.(controller).generators
+	links := difficultyLinks(readTestDictionary(t))
+
+	for _, difficulty := range difficulties {
+		for length := MinChainLength; length <= MaxChainLength; length++ {
+			options := GameOptions{Length: length, Difficulty: difficulty}
+			game, err := c.CreateGame(context.Background(), options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			chain := game.GeneratedChain
+			if game.GameOptions != options || len(chain) != length {
+				t.Fatalf("expected a %s chain of %d words, got %+v", difficulty, length, game.GameState)
+			}
+			// Every link is at most as hard as the difficulty, and at least
+			// one is from the difficulty's own third
+			ownLinks := 0
+			for i := range len(chain) - 1 {
+				if !slices.Contains(generators[difficulty].dictionary[chain[i]], chain[i+1]) {
+					t.Fatalf("%s chain %v links %s to %s", difficulty, chain, chain[i], chain[i+1])
+				}
+				if slices.Contains(links[difficulty][chain[i]], chain[i+1]) {
+					ownLinks++
+				}
+			}
+			if ownLinks == 0 {
+				t.Fatalf("%s chain %v has no %s links", difficulty, chain, difficulty)
+			}
+		}
+	}
+}
+
+func countLinks(dictionary Dictionary) int {
+	links := 0
+	for _, nextWords := range dictionary {
+		links += len(nextWords)
+	}
+
+	return links
+}
@@ -1,5 +1,7 @@
 package wordchain
 
+import "slices"
+
 // Dictionary is the type for the dictionary of the WordChain game
 type Dictionary map[string][]string
 
@@ -16,8 +18,37 @@ type Game struct {
 	EncryptedState string `json:"encryptedState"`
 }
 
+// Difficulty represents how hard the links between words in a chain are
+type Difficulty string
+
+const (
+	// EasyDifficulty only uses the most guessable links
+	EasyDifficulty Difficulty = "easy"
+	// MediumDifficulty is the default difficulty
+	MediumDifficulty Difficulty = "medium"
+	// HardDifficulty can use any link in the dictionary
+	HardDifficulty Difficulty = "hard"
+)
+
+// difficulties are the difficulties from easiest to hardest
+var difficulties = []Difficulty{EasyDifficulty, MediumDifficulty, HardDifficulty}
+
+// IsValid returns true if the difficulty is a known difficulty
+func (d Difficulty) IsValid() bool {
+	return slices.Contains(difficulties, d)
+}
+
+// GameOptions are the options a chain is generated with
+type GameOptions struct {
+	// Length is the number of words in the chain
+	Length     int        `json:"length"`
+	Difficulty Difficulty `json:"difficulty"`
+}
+
 // GameState is the relevant game state for Word Chain
 type GameState struct {
+	GameOptions
+
 	// GeneratedChain is the readable format of the game
 	GeneratedChain Chain `json:"generatedChain"`
 	// UserProgress is the word in the chain that the user is
@@ -36,3 +67,156 @@ type ValidateAnswerResponse struct {
 	Correct     bool `json:"correct"`
 	UpdatedGame Game `json:"updatedGame"`
 }
+
+// LobbyStatus is the stage a multiplayer lobby is at
+type LobbyStatus string
+
+const (
+	// WaitingStatus - players can join, until the host starts the game
+	WaitingStatus LobbyStatus = "waiting"
+	// PlayingStatus - the players are racing to finish the chain
+	PlayingStatus LobbyStatus = "playing"
+	// FinishedStatus - a player has finished the chain
+	FinishedStatus LobbyStatus = "finished"
+)
+
+// LobbyPlayer is a player in a lobby, as seen by every player
+type LobbyPlayer struct {
+	Name string `json:"name"`
+	// Host is true for the player that can start the game
+	Host bool `json:"host"`
+	// Progress is the word in the chain that the player is currently
+	// guessing
+	Progress int `json:"progress"`
+	// Connected is true while the player has a live connection to the
+	// lobby, such as a WebSocket
+	Connected bool `json:"connected"`
+	Finished  bool `json:"finished"`
+}
+
+// Lobby is a multiplayer game where every player solves the same chain
+type Lobby struct {
+	Code       string        `json:"code"`
+	Status     LobbyStatus   `json:"status"`
+	Players    []LobbyPlayer `json:"players"`
+	MaxPlayers int           `json:"maxPlayers"`
+	// Hints are the first word of the chain followed by the first letter
+	// of every other word. Only set once the game has started
+	Hints []string `json:"hints,omitempty"`
+	// Chain is the whole chain, only set once the game has finished
+	Chain Chain `json:"chain,omitempty"`
+	// Winner is the name of the first player to finish the chain
+	Winner string `json:"winner,omitempty"`
+}
+
+// LobbyMembership is given to a player when they create or join a lobby
+type LobbyMembership struct {
+	// PlayerID identifies the player for the rest of the game. It is only
+	// given to the player it belongs to
+	PlayerID string `json:"playerId"`
+	Lobby    Lobby  `json:"lobby"`
+}
+
+// JoinLobbyRequest is the body of an HTTP request to create or join a
+// lobby
+type JoinLobbyRequest struct {
+	Name string `json:"name"`
+}
+
+// LobbyPlayerRequest is the body of an HTTP request made by a player in
+// a lobby, such as starting or leaving it
+type LobbyPlayerRequest struct {
+	PlayerID string `json:"playerId"`
+}
+
+// LobbyGuessRequest is the body of an HTTP request to guess the next
+// word of a lobby's chain
+type LobbyGuessRequest struct {
+	PlayerID string `json:"playerId"`
+	Guess    string `json:"guess"`
+}
+
+// LobbyGuessResponse is the response given to a LobbyGuessRequest
+type LobbyGuessResponse struct {
+	Correct bool `json:"correct"`
+	// Word is the word that was guessed, if it was correct
+	Word  string `json:"word,omitempty"`
+	Lobby Lobby  `json:"lobby"`
+}
+
+// LobbyEventType is the kind of change a LobbyEvent is about
+type LobbyEventType string
+
+const (
+	// PlayerJoinedEvent - a player joined the lobby
+	PlayerJoinedEvent LobbyEventType = "player-joined"
+	// PlayerLeftEvent - a player left the lobby
+	PlayerLeftEvent LobbyEventType = "player-left"
+	// PlayerConnectedEvent - a player opened a live connection
+	PlayerConnectedEvent LobbyEventType = "player-connected"
+	// PlayerDisconnectedEvent - a player lost their last live connection,
+	// and will leave unless they reconnect soon
+	PlayerDisconnectedEvent LobbyEventType = "player-disconnected"
+	// GameStartedEvent - the host started the game
+	GameStartedEvent LobbyEventType = "game-started"
+	// ProgressEvent - a player guessed their next word
+	ProgressEvent LobbyEventType = "progress"
+	// GameFinishedEvent - a player finished the chain and won
+	GameFinishedEvent LobbyEventType = "game-finished"
+	// LobbyClosedEvent - every player left, or the lobby expired. It is
+	// always the last event
+	LobbyClosedEvent LobbyEventType = "lobby-closed"
+)
+
+// LobbyEvent is a change to a lobby
+type LobbyEvent struct {
+	// ID is the position of the event in the lobby, starting at 1
+	ID   int            `json:"id"`
+	Type LobbyEventType `json:"type"`
+	// Player is the name of the player the event is about, if any
+	Player string `json:"player,omitempty"`
+	// Lobby is the lobby after the event
+	Lobby Lobby `json:"lobby"`
+}
+
+// SocketMessageType is the kind of a message sent over a lobby's WebSocket
+type SocketMessageType string
+
+const (
+	// GuessMessage - the player guesses their next word
+	GuessMessage SocketMessageType = "guess"
+	// StartMessage - the host starts the game
+	StartMessage SocketMessageType = "start"
+	// LeaveMessage - the player leaves the lobby and closes the connection
+	LeaveMessage SocketMessageType = "leave"
+	// PingMessage - the player checks the connection is alive, and is
+	// sent a PongMessage
+	PingMessage SocketMessageType = "ping"
+
+	// EventMessage - a change to the lobby
+	EventMessage SocketMessageType = "event"
+	// GuessResultMessage - the result of the player's guess
+	GuessResultMessage SocketMessageType = "guess-result"
+	// PongMessage - the reply to a PingMessage
+	PongMessage SocketMessageType = "pong"
+	// ErrorMessage - the player's last message failed
+	ErrorMessage SocketMessageType = "error"
+)
+
+// SocketRequest is a message sent by a player over a lobby's WebSocket
+type SocketRequest struct {
+	Type SocketMessageType `json:"type"`
+	// Guess is set for a GuessMessage
+	Guess string `json:"guess,omitempty"`
+}
+
+// SocketResponse is a message sent to a player over a lobby's WebSocket
+type SocketResponse struct {
+	Type SocketMessageType `json:"type"`
+	// Event is set for an EventMessage
+	Event *LobbyEvent `json:"event,omitempty"`
+	// GuessResult is set for a GuessResultMessage
+	GuessResult *LobbyGuessResponse `json:"guessResult,omitempty"`
+	// Error is set for an ErrorMessage
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,198 @@
+package wordchain
+
+import (
+	"context"
+	"errors"
+	"web_games/utils"
+)
+
+// ErrNoChain is returned when the dictionary has no chain of the length,
+// or none could be found within maxGenerationSteps
+var ErrNoChain = errors.New("No chain of that length can be made")
+
+// maxGenerationSteps is the most words that are tried while generating a
+// chain, which bounds how long a dictionary with many dead ends can take
+const maxGenerationSteps = 100000
+
+// chainGenerator generates random chains from a dictionary, knowing ahead
+// of time which words can carry on a chain so that it never picks a word
+// that leads to a dead end
+type chainGenerator struct {
+	dictionary Dictionary
+	// required are the links that every chain of more than one word must
+	// use at least one of, so that it is as hard as its difficulty. nil if
+	// any links can be used
+	required map[string]map[string]bool
+	// canReach[k] has every word that starts a sequence of k+1 linked
+	// words. Sequences can repeat words, so a word in canReach[k] might
+	// still only start shorter chains
+	canReach []map[string]bool
+	// canReachRequired[k] has every word that starts a sequence of k+1
+	// linked words using at least one required link
+	canReachRequired []map[string]bool
+	// maxSteps is the most words that are tried while generating a chain
+	maxSteps int
+}
+
+// newChainGenerator creates a generator for chains of up to maxLength
+// words, which use at least one of the required links if there are any
+func newChainGenerator(dictionary Dictionary, required Dictionary, maxLength int) chainGenerator {
+	generator := chainGenerator{
+		dictionary:       dictionary,
+		canReach:         make([]map[string]bool, max(maxLength, 1)),
+		canReachRequired: make([]map[string]bool, max(maxLength, 1)),
+		maxSteps:         maxGenerationSteps,
+	}
+	if required != nil {
+		generator.required = map[string]map[string]bool{}
+		for word, nextWords := range required {
+			generator.required[word] = map[string]bool{}
+			for _, nextWord := range nextWords {
+				generator.required[word][nextWord] = true
+			}
+		}
+	}
+
+	// Every word, including words that don't link to any others, is a
+	// sequence of one word
+	generator.canReach[0] = map[string]bool{}
+	for word, nextWords := range dictionary {
+		generator.canReach[0][word] = true
+		for _, nextWord := range nextWords {
+			generator.canReach[0][nextWord] = true
+		}
+	}
+
+	// A single word has no links, so it can't use a required one
+	generator.canReachRequired[0] = map[string]bool{}
+
+	for k := 1; k < len(generator.canReach); k++ {
+		generator.canReach[k] = map[string]bool{}
+		generator.canReachRequired[k] = map[string]bool{}
+		for word, nextWords := range dictionary {
+			if utils.Any(nextWords, func(nextWord string) bool {
+				return generator.canReach[k-1][nextWord]
+			}) {
+				generator.canReach[k][word] = true
+			}
+			if utils.Any(nextWords, func(nextWord string) bool {
+				return generator.canFollow(word, nextWord, k, false)
+			}) {
+				generator.canReachRequired[k][word] = true
+			}
+		}
+	}
+
+	return generator
+}
+
+// canFollow returns true if nextWord can follow word in a chain that
+// still needs remaining words after word. hasRequired is whether the
+// chain already uses a required link
+func (g chainGenerator) canFollow(word string, nextWord string, remaining int, hasRequired bool) bool {
+	if hasRequired || g.required == nil || g.required[word][nextWord] {
+		return g.canReach[remaining-1][nextWord]
+	}
+
+	return g.canReachRequired[remaining-1][nextWord]
+}
+
+// starts gets every word that can start a chain of length words
+func (g chainGenerator) starts(length int) map[string]bool {
+	if g.required == nil || length == 1 {
+		return g.canReach[length-1]
+	}
+
+	return g.canReachRequired[length-1]
+}
+
+// newChainGenerators creates a generator for each difficulty, for chains
+// of up to MaxChainLength words. Every chain uses at least one link from
+// its difficulty's own third, so that harder chains can't be made of only
+// easier links
+func newChainGenerators(dictionary Dictionary) map[Difficulty]chainGenerator {
+	links := difficultyLinks(dictionary)
+
+	generators := map[Difficulty]chainGenerator{}
+	for difficulty, difficultyDictionary := range newDifficultyDictionaries(dictionary) {
+		generators[difficulty] = newChainGenerator(difficultyDictionary, links[difficulty], MaxChainLength)
+	}
+
+	return generators
+}
+
+// chainFrame is a position in the chain being generated, with the words
+// that haven't been tried there yet
+type chainFrame struct {
+	candidates []string
+}
+
+// generate generates a random chain of length distinct words. Each word is
+// picked at random from the words that can still finish the chain, and if
+// repeated words leave no way to finish it, earlier words are tried again.
+// Every possible chain is tried at most once, and at most maxSteps words
+// are tried, so it always finishes, with ErrNoChain if no chain of that
+// length was found
+func (g chainGenerator) generate(ctx context.Context, length int) (Chain, error) {
+	if length < 1 || length > len(g.canReach) {
+		return nil, ErrNoChain
+	}
+
+	starts := []string{}
+	for word := range g.starts(length) {
+		starts = append(starts, word)
+	}
+
+	chain := Chain{}
+	inChain := map[string]bool{}
+	// requiredLinks counts the required links in the chain
+	requiredLinks := 0
+	// There is a frame for each word in the chain, and one more for the
+	// next word to pick
+	stack := []chainFrame{{candidates: utils.ShuffleSlice(starts)}}
+	for steps := 0; len(stack) > 0 && steps < g.maxSteps; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		frame := &stack[len(stack)-1]
+		if len(frame.candidates) == 0 {
+			// Every word has been tried here, so go back to try another word
+			// in the position before
+			stack = stack[:len(stack)-1]
+			if len(chain) > 0 {
+				if g.isRequired(chain) {
+					requiredLinks--
+				}
+				delete(inChain, chain[len(chain)-1])
+				chain = chain[:len(chain)-1]
+			}
+			continue
+		}
+
+		word := frame.candidates[0]
+		frame.candidates = frame.candidates[1:]
+		steps++
+		chain = append(chain, word)
+		inChain[word] = true
+		if g.isRequired(chain) {
+			requiredLinks++
+		}
+		if len(chain) == length {
+			return chain, nil
+		}
+
+		remaining := length - len(chain)
+		nextWords := utils.Filter(g.dictionary[word], func(nextWord string) bool {
+			return !inChain[nextWord] && g.canFollow(word, nextWord, remaining, requiredLinks > 0)
+		})
+		stack = append(stack, chainFrame{candidates: utils.ShuffleSlice(nextWords)})
+	}
+
+	return nil, ErrNoChain
+}
+
+// isRequired returns true if the last link of the chain is required
+func (g chainGenerator) isRequired(chain Chain) bool {
+	return len(chain) >= 2 && g.required[chain[len(chain)-2]][chain[len(chain)-1]]
+}
@@ -0,0 +1,134 @@
+package wordchain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// chainsPerLength is how many chains are generated for each difficulty
+// and length
+const chainsPerLength = 50
+
+func TestChainGeneratorReachability(t *testing.T) {
+	for difficulty, generator := range newChainGenerators(readTestDictionary(t)) {
+		for k := 1; k < len(generator.canReach); k++ {
+			for word := range generator.canReach[k] {
+				// A word that starts a longer sequence starts every shorter one
+				if !generator.canReach[k-1][word] {
+					t.Fatalf("%s: %s is in canReach[%d] but not canReach[%d]", difficulty, word, k, k-1)
+				}
+			}
+
+			for word, nextWords := range generator.dictionary {
+				reaches := slices.ContainsFunc(nextWords, func(nextWord string) bool {
+					return generator.canReach[k-1][nextWord]
+				})
+				if reaches != generator.canReach[k][word] {
+					t.Fatalf("%s: canReach[%d][%s] is %v, but its links say %v", difficulty, k, word, generator.canReach[k][word], reaches)
+				}
+			}
+		}
+	}
+}
+
+func TestChainGeneratorChains(t *testing.T) {
+	ctx := context.Background()
+	for difficulty, generator := range newChainGenerators(readTestDictionary(t)) {
+		for length := 1; length <= MaxChainLength; length++ {
+			chains := map[string]bool{}
+			for range chainsPerLength {
+				chain, err := generator.generate(ctx, length)
+				if err != nil {
+					t.Fatalf("%s chain of %d: %v", difficulty, length, err)
+				}
+
+				if len(chain) != length {
+					t.Fatalf("%s: expected %d words, got %v", difficulty, length, chain)
+				}
+				if !generator.canReach[length-1][chain[0]] {
+					t.Fatalf("%s: %v starts with a word that can't reach %d words", difficulty, chain, length)
+				}
+				for i := range len(chain) - 1 {
+					if !slices.Contains(generator.dictionary[chain[i]], chain[i+1]) {
+						t.Fatalf("%s: %v links %s to %s", difficulty, chain, chain[i], chain[i+1])
+					}
+				}
+				// Every chain has a link from its difficulty's own third
+				hasRequired := false
+				for i := 2; i <= len(chain); i++ {
+					hasRequired = hasRequired || generator.isRequired(chain[:i])
+				}
+				if length >= 2 && !hasRequired {
+					t.Fatalf("%s: %v has no %s link", difficulty, chain, difficulty)
+				}
+				sorted := slices.Clone(chain)
+				slices.Sort(sorted)
+				if len(slices.Compact(sorted)) != length {
+					t.Fatalf("%s: %v repeats a word", difficulty, chain)
+				}
+
+				chains[strings.Join(chain, " ")] = true
+			}
+
+			// Longer chains are random enough to almost never repeat
+			if length >= 3 && len(chains) < chainsPerLength/2 {
+				t.Errorf("%s: only %d different chains of %d words", difficulty, len(chains), length)
+			}
+		}
+	}
+}
+
+func TestChainGeneratorNoChain(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		dictionary Dictionary
+		required   Dictionary
+		length     int
+	}{
+		{name: "empty dictionary", dictionary: Dictionary{}, length: 1},
+		{name: "longer than the maximum", dictionary: testDictionary, length: MaxChainLength + 1},
+		{name: "longer than the dictionary", dictionary: testDictionary, length: len(testDictionary) + 1},
+		// Sequences of any length can be made by going around the cycle, but
+		// every chain of more than two words has to repeat one
+		{name: "only a cycle", dictionary: Dictionary{"up": {"down"}, "down": {"up"}}, length: 3},
+		{name: "dead ends", dictionary: Dictionary{"a": {"b", "c"}, "b": {"a"}, "c": {"b"}}, length: 4},
+		// The only chain of three words doesn't use the required link
+		{name: "no required link", dictionary: Dictionary{"a": {"b", "c"}, "b": {"c"}}, required: Dictionary{"a": {"c"}}, length: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generator := newChainGenerator(test.dictionary, test.required, MaxChainLength)
+			chain, err := generator.generate(ctx, test.length)
+			if !errors.Is(err, ErrNoChain) {
+				t.Errorf("expected ErrNoChain, got %v, %v", chain, err)
+			}
+		})
+	}
+
+	// The longest chains that can be made are still found
+	generator := newChainGenerator(Dictionary{"a": {"b", "c"}, "b": {"a"}, "c": {"b"}}, nil, MaxChainLength)
+	chain, err := generator.generate(ctx, 3)
+	if err != nil || len(chain) != 3 {
+		t.Errorf("expected a chain of 3 words, got %v, %v", chain, err)
+	}
+
+	// Generation gives up once it has tried too many words
+	generator = newChainGenerator(testDictionary, nil, MaxChainLength)
+	generator.maxSteps = len(testDictionary) - 1
+	chain, err = generator.generate(ctx, len(testDictionary))
+	if !errors.Is(err, ErrNoChain) {
+		t.Errorf("expected ErrNoChain, got %v, %v", chain, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = newChainGenerator(testDictionary, nil, MaxChainLength).generate(cancelled, 3)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
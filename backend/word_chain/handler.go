@@ -2,29 +2,73 @@ package wordchain
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"web_games/utils"
+)
+
+const (
+	// lobbyHeartbeatInterval is how often a comment is sent on a quiet
+	// event stream, so that it isn't closed for being idle
+	lobbyHeartbeatInterval = 15 * time.Second
+	// lobbyRetryMs is how long browsers wait before reconnecting to a
+	// dropped event stream
+	lobbyRetryMs = 3000
 )
 
 // Handler reprenents a word ladder handler
 type Handler interface {
 	NewGame(w http.ResponseWriter, r *http.Request)
 	CreateLobby(w http.ResponseWriter, r *http.Request)
+	GetLobby(w http.ResponseWriter, r *http.Request)
+	JoinLobby(w http.ResponseWriter, r *http.Request)
+	LeaveLobby(w http.ResponseWriter, r *http.Request)
+	StartLobby(w http.ResponseWriter, r *http.Request)
+	GuessLobby(w http.ResponseWriter, r *http.Request)
+	LobbyEvents(w http.ResponseWriter, r *http.Request)
+	LobbySocket(w http.ResponseWriter, r *http.Request)
 	ValidateAnswer(w http.ResponseWriter, r *http.Request)
 }
 
 type handler struct {
 	controller Controller
+	// originPatterns are the hosts of the sites allowed to open a
+	// WebSocket from another origin
+	originPatterns []string
 }
 
-// NewHandler creates a new handler
-func NewHandler(controller Controller) Handler {
+// NewHandler creates a new handler. allowedOrigins are the URLs of the
+// sites, other than this server, allowed to open WebSockets
+func NewHandler(controller Controller, allowedOrigins []string) Handler {
+	originPatterns := []string{}
+	for _, origin := range allowedOrigins {
+		originURL, err := url.Parse(origin)
+		if err == nil && originURL.Host != "" {
+			originPatterns = append(originPatterns, originURL.Host)
+		}
+	}
+
 	return handler{
-		controller: controller,
+		controller:     controller,
+		originPatterns: originPatterns,
 	}
 }
 
 func (h handler) NewGame(w http.ResponseWriter, r *http.Request) {
-	game, err := h.controller.CreateGame(r.Context())
+	options, ok := getGameOptions(w, r)
+	if !ok {
+		return
+	}
+
+	game, err := h.controller.CreateGame(r.Context(), options)
+	if errors.Is(err, ErrNoChain) {
+		sendResponse(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -40,8 +84,140 @@ func (h handler) NewGame(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h handler) CreateLobby(w http.ResponseWriter, r *http.Request) {
-	// TODO
-	w.WriteHeader(http.StatusNotImplemented)
+	var joinRequest JoinLobbyRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&joinRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.controller.CreateMultiplayerLobby(r.Context(), joinRequest.Name, utils.ClientAddress(r))
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, membership)
+}
+
+func (h handler) GetLobby(w http.ResponseWriter, r *http.Request) {
+	lobby, err := h.controller.GetLobby(r.PathValue("code"))
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, lobby)
+}
+
+func (h handler) JoinLobby(w http.ResponseWriter, r *http.Request) {
+	var joinRequest JoinLobbyRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&joinRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	membership, err := h.controller.JoinLobby(r.PathValue("code"), joinRequest.Name)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, membership)
+}
+
+func (h handler) LeaveLobby(w http.ResponseWriter, r *http.Request) {
+	var playerRequest LobbyPlayerRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&playerRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	lobby, err := h.controller.LeaveLobby(r.PathValue("code"), playerRequest.PlayerID)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, lobby)
+}
+
+func (h handler) StartLobby(w http.ResponseWriter, r *http.Request) {
+	var playerRequest LobbyPlayerRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&playerRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	lobby, err := h.controller.StartLobby(r.Context(), r.PathValue("code"), playerRequest.PlayerID)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, lobby)
+}
+
+func (h handler) GuessLobby(w http.ResponseWriter, r *http.Request) {
+	var guessRequest LobbyGuessRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&guessRequest)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.controller.GuessLobby(r.PathValue("code"), guessRequest.PlayerID, guessRequest.Guess)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	sendResponse(w, http.StatusOK, response)
+}
+
+// LobbyEvents streams a lobby's events as Server-Sent Events. A stream
+// that reconnects with Last-Event-ID resumes after that event
+func (h handler) LobbyEvents(w http.ResponseWriter, r *http.Request) {
+	lastEventID, ok := getLastEventID(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.controller.LobbyEvents(r.Context(), r.PathValue("code"), lastEventID)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+
+	fmt.Fprintf(w, "retry: %d\n\n", lobbyRetryMs)
+
+	heartbeat := time.NewTicker(lobbyHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			// The lobby has closed or the client has gone
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+	}
 }
 
 func (h handler) ValidateAnswer(w http.ResponseWriter, r *http.Request) {
@@ -69,3 +245,92 @@ func sendResponse(w http.ResponseWriter, status int, data any) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+// writeLobbyError writes the status and message for an error from a lobby
+func writeLobbyError(w http.ResponseWriter, err error) {
+	status, message := lobbyErrorResponse(err)
+	sendResponse(w, status, message)
+}
+
+// lobbyErrorResponse gets the status and message for an error from a
+// lobby. Unexpected errors aren't shown to players
+func lobbyErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrLobbyNotFound):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, ErrPlayerNotFound), errors.Is(err, ErrNotHost):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, ErrTooManyLobbies):
+		return http.StatusServiceUnavailable, err.Error()
+	case errors.Is(err, ErrTooManyClientLobbies):
+		return http.StatusTooManyRequests, err.Error()
+	case errors.Is(err, ErrInvalidName):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, ErrNoChain):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, ErrLobbyFull),
+		errors.Is(err, ErrLobbyStarted),
+		errors.Is(err, ErrLobbyNotPlaying),
+		errors.Is(err, ErrNotEnoughPlayers),
+		errors.Is(err, ErrNameTaken):
+		return http.StatusConflict, err.Error()
+	default:
+		return http.StatusInternalServerError, "Error updating lobby"
+	}
+}
+
+// getGameOptions reads the length and difficulty from the request, which
+// default to DefaultChainLength and medium. If an option is not valid, the
+// error is written and ok is false
+func getGameOptions(w http.ResponseWriter, r *http.Request) (GameOptions, bool) {
+	options := GameOptions{
+		Length:     DefaultChainLength,
+		Difficulty: Difficulty(r.URL.Query().Get("difficulty")),
+	}
+
+	lengthStr := r.URL.Query().Get("length")
+	if lengthStr != "" {
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil || length < MinChainLength || length > MaxChainLength {
+			sendResponse(w, http.StatusBadRequest, fmt.Sprintf(
+				"Length must be between %d and %d",
+				MinChainLength,
+				MaxChainLength,
+			))
+			return GameOptions{}, false
+		}
+		options.Length = length
+	}
+
+	if options.Difficulty == "" {
+		options.Difficulty = MediumDifficulty
+	}
+	if !options.Difficulty.IsValid() {
+		sendResponse(w, http.StatusBadRequest, "Difficulty must be easy, medium or hard")
+		return GameOptions{}, false
+	}
+
+	return options, true
+}
+
+// getLastEventID gets the ID of the last event a client has seen, from the
+// Last-Event-ID header that browsers send when reconnecting, or the
+// lastEventId query parameter. If the ID is invalid, the error is written
+// and ok is false
+func getLastEventID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	lastEventIDStr := r.Header.Get("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = r.URL.Query().Get("lastEventId")
+	}
+	if lastEventIDStr == "" {
+		return 0, true
+	}
+
+	lastEventID, err := strconv.Atoi(lastEventIDStr)
+	if err != nil || lastEventID < 0 {
+		sendResponse(w, http.StatusBadRequest, "Last event ID must be a non-negative integer")
+		return 0, false
+	}
+
+	return lastEventID, true
+}
@@ -0,0 +1,599 @@
+package wordchain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"web_games/utils"
+)
+
+var (
+	// ErrLobbyNotFound is returned when no lobby has the code
+	ErrLobbyNotFound = errors.New("Lobby not found")
+	// ErrTooManyLobbies is returned when the maximum number of lobbies are
+	// already running
+	ErrTooManyLobbies = errors.New("Too many lobbies are running, try again later")
+	// ErrLobbyFull is returned when joining a lobby with no space left
+	ErrLobbyFull = errors.New("Lobby is full")
+	// ErrLobbyStarted is returned when joining or starting a lobby that
+	// has already started
+	ErrLobbyStarted = errors.New("Lobby has already started")
+	// ErrLobbyNotPlaying is returned when guessing in a lobby that isn't
+	// playing
+	ErrLobbyNotPlaying = errors.New("Lobby is not playing")
+	// ErrNotHost is returned when a player other than the host starts a
+	// lobby
+	ErrNotHost = errors.New("Only the host can start the game")
+	// ErrNotEnoughPlayers is returned when starting a lobby without
+	// enough players
+	ErrNotEnoughPlayers = errors.New("Not enough players to start")
+	// ErrPlayerNotFound is returned when a player isn't in the lobby
+	ErrPlayerNotFound = errors.New("Player not found")
+	// ErrInvalidName is returned when a player's name is empty or too long
+	ErrInvalidName = errors.New("Name must be between 1 and 20 characters")
+	// ErrNameTaken is returned when another player in the lobby has the
+	// same name
+	ErrNameTaken = errors.New("Name is already taken")
+	// ErrTooManyClientLobbies is returned when a client creating a lobby
+	// already has as many lobbies running as they are allowed
+	ErrTooManyClientLobbies = errors.New("You already have a lobby running")
+)
+
+const (
+	// lobbyPruneInterval is how often expired lobbies are removed
+	lobbyPruneInterval = 30 * time.Second
+	// maxLobbiesPerClient is the most lobbies a single client can have
+	// running at once
+	maxLobbiesPerClient = 1
+	// minLobbyPlayers is the fewest players a game can be started with
+	minLobbyPlayers = 2
+	// maxNameLength is the most characters a player's name can have
+	maxNameLength = 20
+	// maxLobbyEvents is the most events kept for players to catch up on
+	maxLobbyEvents = 500
+	// reconnectGracePeriod is how long a player that has lost their
+	// connection has to reconnect before they leave the lobby
+	reconnectGracePeriod = 30 * time.Second
+)
+
+// lobbyExpiries is how long a lobby with each status is kept after its
+// last change. Lobbies that are waiting to start or have finished aren't
+// being played, so they are removed much sooner than lobbies that are
+var lobbyExpiries = map[LobbyStatus]time.Duration{
+	WaitingStatus:  10 * time.Minute,
+	PlayingStatus:  30 * time.Minute,
+	FinishedStatus: 2 * time.Minute,
+}
+
+// lobbyPlayer is a player in a lobby
+type lobbyPlayer struct {
+	id       string
+	name     string
+	progress int
+	// connections is the number of live connections the player has
+	connections int
+	// leaveTimer removes the player once they have been disconnected for
+	// the grace period
+	leaveTimer *time.Timer
+}
+
+// lobby is a multiplayer game, where every player races to solve the
+// same chain
+type lobby struct {
+	lock sync.Mutex
+
+	code       string
+	maxPlayers int
+	// client is who created the lobby, to limit how many each client has
+	client string
+	status LobbyStatus
+	// players are in the order they joined. The first player is the host
+	players []*lobbyPlayer
+	chain   Chain
+	winner  string
+	// lastActive is when the lobby was last changed, to know when it has
+	// expired
+	lastActive time.Time
+	// closed is set once the lobby has been removed, so that it can't be
+	// changed any more
+	closed bool
+
+	// events are the latest changes to the lobby, for players to follow
+	events      []LobbyEvent
+	nextEventID int
+	// changed is closed and replaced whenever an event is recorded, to
+	// wake up everyone waiting for one
+	changed chan struct{}
+}
+
+// newLobby creates an empty lobby for a client
+func newLobby(code string, maxPlayers int, client string) *lobby {
+	return &lobby{
+		code:        code,
+		maxPlayers:  maxPlayers,
+		client:      client,
+		status:      WaitingStatus,
+		players:     []*lobbyPlayer{},
+		lastActive:  time.Now(),
+		events:      []LobbyEvent{},
+		nextEventID: 1,
+		changed:     make(chan struct{}),
+	}
+}
+
+// join adds a player to the lobby
+func (l *lobby) join(name string) (*lobbyPlayer, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, ErrInvalidName
+	}
+	if l.closed {
+		return nil, ErrLobbyNotFound
+	}
+	if l.status != WaitingStatus {
+		return nil, ErrLobbyStarted
+	}
+	if len(l.players) >= l.maxPlayers {
+		return nil, ErrLobbyFull
+	}
+	for _, player := range l.players {
+		if strings.EqualFold(player.name, name) {
+			return nil, ErrNameTaken
+		}
+	}
+
+	player := &lobbyPlayer{
+		id:       utils.NewUUIDString(),
+		name:     name,
+		progress: 1,
+	}
+	l.players = append(l.players, player)
+	l.record(PlayerJoinedEvent, name)
+
+	return player, nil
+}
+
+// leave removes a player from the lobby. If the host leaves, the next
+// player to have joined becomes the host, and once every player has left
+// the lobby is closed
+func (l *lobby) leave(playerID string) error {
+	index := slices.IndexFunc(l.players, func(player *lobbyPlayer) bool {
+		return player.id == playerID
+	})
+	if index == -1 {
+		return ErrPlayerNotFound
+	}
+
+	name := l.players[index].name
+	l.players = slices.Delete(l.players, index, index+1)
+	l.record(PlayerLeftEvent, name)
+	if len(l.players) == 0 {
+		l.close()
+	}
+
+	return nil
+}
+
+// connect adds a live connection for a player, stopping them from being
+// removed for having disconnected
+func (l *lobby) connect(playerID string) error {
+	player, ok := l.player(playerID)
+	if !ok || l.closed {
+		return ErrPlayerNotFound
+	}
+
+	if player.leaveTimer != nil {
+		player.leaveTimer.Stop()
+		player.leaveTimer = nil
+	}
+	player.connections++
+	if player.connections == 1 {
+		l.record(PlayerConnectedEvent, player.name)
+	}
+
+	return nil
+}
+
+// disconnect removes a live connection for a player. Once the player has
+// no connections, leave is called after the grace period unless they
+// reconnect
+func (l *lobby) disconnect(playerID string, leave func()) {
+	player, ok := l.player(playerID)
+	if !ok || player.connections == 0 {
+		return
+	}
+
+	player.connections--
+	if player.connections == 0 && !l.closed {
+		player.leaveTimer = time.AfterFunc(reconnectGracePeriod, leave)
+		l.record(PlayerDisconnectedEvent, player.name)
+	}
+}
+
+// leaveIfDisconnected removes a player if they still have no connections
+func (l *lobby) leaveIfDisconnected(playerID string) error {
+	player, ok := l.player(playerID)
+	if !ok {
+		return ErrPlayerNotFound
+	}
+	// The player reconnected as the timer fired
+	if player.connections > 0 {
+		return nil
+	}
+
+	return l.leave(playerID)
+}
+
+// expired returns true if the lobby has gone unchanged for longer than
+// lobbies with its status are kept
+func (l *lobby) expired(now time.Time) bool {
+	return now.Sub(l.lastActive) > lobbyExpiries[l.status]
+}
+
+// close stops the lobby from being changed any more
+func (l *lobby) close() {
+	if l.closed {
+		return
+	}
+
+	l.closed = true
+	l.record(LobbyClosedEvent, "")
+}
+
+// start starts the game with the chain. checkCanStart must be called
+// first
+func (l *lobby) start(chain Chain) {
+	l.status = PlayingStatus
+	l.chain = chain
+	l.record(GameStartedEvent, l.players[0].name)
+}
+
+// checkCanStart returns an error if the player can't start the game. Only
+// the host can start it
+func (l *lobby) checkCanStart(playerID string) error {
+	player, ok := l.player(playerID)
+	if !ok {
+		return ErrPlayerNotFound
+	}
+	if l.status != WaitingStatus {
+		return ErrLobbyStarted
+	}
+	if player != l.players[0] {
+		return ErrNotHost
+	}
+	if len(l.players) < minLobbyPlayers {
+		return ErrNotEnoughPlayers
+	}
+
+	return nil
+}
+
+// guess checks a player's guess of their next word. The first player to
+// guess every word wins and finishes the game
+func (l *lobby) guess(playerID string, guess string) (bool, string, error) {
+	player, ok := l.player(playerID)
+	if !ok {
+		return false, "", ErrPlayerNotFound
+	}
+	if l.status != PlayingStatus {
+		return false, "", ErrLobbyNotPlaying
+	}
+
+	word := l.chain[player.progress]
+	if !strings.EqualFold(guess, word) {
+		return false, "", nil
+	}
+
+	player.progress++
+	l.record(ProgressEvent, player.name)
+	if player.progress == len(l.chain) {
+		l.status = FinishedStatus
+		l.winner = player.name
+		l.record(GameFinishedEvent, player.name)
+	}
+
+	return true, word, nil
+}
+
+// record adds an event for a change to the lobby, waking up everyone
+// waiting for one
+func (l *lobby) record(eventType LobbyEventType, player string) {
+	l.events = append(l.events, LobbyEvent{
+		ID:     l.nextEventID,
+		Type:   eventType,
+		Player: player,
+		Lobby:  l.view(),
+	})
+	l.nextEventID++
+	if len(l.events) > maxLobbyEvents {
+		l.events = slices.Delete(l.events, 0, len(l.events)-maxLobbyEvents)
+	}
+	l.lastActive = time.Now()
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// eventsAfter gets the events after lastEventID, and a channel that is
+// closed when the next event is recorded
+func (l *lobby) eventsAfter(lastEventID int) ([]LobbyEvent, <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	index := slices.IndexFunc(l.events, func(event LobbyEvent) bool {
+		return event.ID > lastEventID
+	})
+	if index == -1 {
+		return []LobbyEvent{}, l.changed
+	}
+
+	return slices.Clone(l.events[index:]), l.changed
+}
+
+// follow sends every event after lastEventID to events, until the lobby
+// closes or ctx is done, and then closes events
+func (l *lobby) follow(ctx context.Context, lastEventID int, events chan<- LobbyEvent) {
+	defer close(events)
+
+	for {
+		pending, changed := l.eventsAfter(lastEventID)
+		for _, event := range pending {
+			select {
+			case events <- event:
+				lastEventID = event.ID
+			case <-ctx.Done():
+				return
+			}
+
+			if event.Type == LobbyClosedEvent {
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// player finds a player by their ID
+func (l *lobby) player(playerID string) (*lobbyPlayer, bool) {
+	for _, player := range l.players {
+		if player.id == playerID {
+			return player, true
+		}
+	}
+
+	return nil, false
+}
+
+// view gets the lobby as seen by every player
+func (l *lobby) view() Lobby {
+	result := Lobby{
+		Code:       l.code,
+		Status:     l.status,
+		Players:    make([]LobbyPlayer, len(l.players)),
+		MaxPlayers: l.maxPlayers,
+		Winner:     l.winner,
+	}
+	for i, player := range l.players {
+		result.Players[i] = LobbyPlayer{
+			Name:      player.name,
+			Host:      i == 0,
+			Progress:  player.progress,
+			Connected: player.connections > 0,
+			Finished:  len(l.chain) > 0 && player.progress == len(l.chain),
+		}
+	}
+
+	if len(l.chain) > 0 {
+		result.Hints = make([]string, len(l.chain))
+		result.Hints[0] = l.chain[0]
+		for i, word := range l.chain[1:] {
+			result.Hints[i+1] = word[:1]
+		}
+	}
+	if l.status == FinishedStatus {
+		result.Chain = slices.Clone(l.chain)
+	}
+
+	return result
+}
+
+// CreateMultiplayerLobby creates a lobby hosted by the named player.
+// client identifies who is creating it, such as by their IP address
+func (c controller) CreateMultiplayerLobby(ctx context.Context, name string, client string) (LobbyMembership, error) {
+	c.lobbyLock.Lock()
+	defer c.lobbyLock.Unlock()
+
+	// Expired lobbies are also removed on a timer, but removing them here
+	// means they never count towards the limits
+	c.pruneLobbies(time.Now())
+	if c.runningGames.Size() >= c.maxServers {
+		return LobbyMembership{}, ErrTooManyLobbies
+	}
+	clientLobbies := c.runningGames.CountFunc(func(_ string, l *lobby) bool {
+		return l.client == client
+	})
+	if clientLobbies >= maxLobbiesPerClient {
+		return LobbyMembership{}, ErrTooManyClientLobbies
+	}
+
+	l := newLobby(c.generateLobbyCode(), c.maxPlayersPerServer, client)
+	player, err := l.join(name)
+	if err != nil {
+		return LobbyMembership{}, err
+	}
+	c.runningGames.Put(l.code, l)
+
+	return LobbyMembership{PlayerID: player.id, Lobby: l.view()}, nil
+}
+
+// pruneExpiredLobbies removes expired lobbies every lobbyPruneInterval,
+// until ctx is done
+func (c controller) pruneExpiredLobbies(ctx context.Context) {
+	ticker := time.NewTicker(lobbyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.pruneLobbies(now)
+		}
+	}
+}
+
+// pruneLobbies closes and removes the lobbies that have expired by now
+func (c controller) pruneLobbies(now time.Time) {
+	c.runningGames.DeleteFunc(func(_ string, l *lobby) bool {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if !l.expired(now) {
+			return false
+		}
+
+		l.close()
+		return true
+	})
+}
+
+// JoinLobby adds the named player to a lobby
+func (c controller) JoinLobby(code string, name string) (LobbyMembership, error) {
+	var player *lobbyPlayer
+	view, err := c.updateLobby(code, func(l *lobby) error {
+		var err error
+		player, err = l.join(name)
+		return err
+	})
+	if err != nil {
+		return LobbyMembership{}, err
+	}
+
+	return LobbyMembership{PlayerID: player.id, Lobby: view}, nil
+}
+
+// GetLobby gets a lobby by its code
+func (c controller) GetLobby(code string) (Lobby, error) {
+	return c.updateLobby(code, func(l *lobby) error {
+		return nil
+	})
+}
+
+// LeaveLobby removes a player from a lobby
+func (c controller) LeaveLobby(code string, playerID string) (Lobby, error) {
+	return c.removePlayer(code, func(l *lobby) error {
+		return l.leave(playerID)
+	})
+}
+
+// ConnectLobby adds a live connection, such as a WebSocket, for a player
+// in a lobby
+func (c controller) ConnectLobby(code string, playerID string) error {
+	_, err := c.updateLobby(code, func(l *lobby) error {
+		return l.connect(playerID)
+	})
+	return err
+}
+
+// DisconnectLobby removes a live connection for a player in a lobby. A
+// player left with no connections leaves the lobby after the grace
+// period, unless they reconnect
+func (c controller) DisconnectLobby(code string, playerID string) {
+	c.updateLobby(code, func(l *lobby) error {
+		l.disconnect(playerID, func() {
+			c.removePlayer(code, func(l *lobby) error {
+				return l.leaveIfDisconnected(playerID)
+			})
+		})
+		return nil
+	})
+}
+
+// removePlayer makes a change to a lobby that may remove a player, and
+// removes the lobby once every player has left
+func (c controller) removePlayer(code string, remove func(l *lobby) error) (Lobby, error) {
+	view, err := c.updateLobby(code, remove)
+	if err != nil {
+		return Lobby{}, err
+	}
+
+	if len(view.Players) == 0 {
+		c.runningGames.Delete(view.Code)
+	}
+
+	return view, nil
+}
+
+// LobbyEvents follows a lobby's events after lastEventID, until the lobby
+// closes or ctx is done. Only the latest events are kept, so a player
+// that has fallen far behind may miss some
+func (c controller) LobbyEvents(ctx context.Context, code string, lastEventID int) (<-chan LobbyEvent, error) {
+	l, ok := c.runningGames.Get(strings.ToUpper(code))
+	if !ok {
+		return nil, ErrLobbyNotFound
+	}
+
+	events := make(chan LobbyEvent)
+	go l.follow(ctx, lastEventID, events)
+
+	return events, nil
+}
+
+// StartLobby generates the lobby's chain and starts the game
+func (c controller) StartLobby(ctx context.Context, code string, playerID string) (Lobby, error) {
+	return c.updateLobby(code, func(l *lobby) error {
+		if err := l.checkCanStart(playerID); err != nil {
+			return err
+		}
+
+		chain, err := c.generators[MediumDifficulty].generate(ctx, DefaultChainLength)
+		if err != nil {
+			return err
+		}
+
+		l.start(chain)
+		return nil
+	})
+}
+
+// GuessLobby checks a player's guess of their next word in a lobby
+func (c controller) GuessLobby(code string, playerID string, guess string) (LobbyGuessResponse, error) {
+	var response LobbyGuessResponse
+	view, err := c.updateLobby(code, func(l *lobby) error {
+		var err error
+		response.Correct, response.Word, err = l.guess(playerID, guess)
+		return err
+	})
+	if err != nil {
+		return LobbyGuessResponse{}, err
+	}
+
+	response.Lobby = view
+	return response, nil
+}
+
+// updateLobby makes a change to a lobby while it is locked
+func (c controller) updateLobby(code string, update func(l *lobby) error) (Lobby, error) {
+	l, ok := c.runningGames.Get(strings.ToUpper(code))
+	if !ok {
+		return Lobby{}, ErrLobbyNotFound
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	err := update(l)
+	if err != nil {
+		return Lobby{}, err
+	}
+
+	return l.view(), nil
+}
@@ -0,0 +1,282 @@
+package wordchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"web_games/services"
+	"web_games/utils"
+)
+
+// testDictionary is a cycle of eight words, so that every chain is made
+// of distinct words. Its links all score the same, so they are split by
+// difficulty in order of their words, which leaves the easy and medium
+// links as one chain of seven words for lobbies to use
+var testDictionary = Dictionary{
+	"cold": {"cord"},
+	"cord": {"card"},
+	"card": {"ward"},
+	"ward": {"warm"},
+	"warm": {"worm"},
+	"worm": {"word"},
+	"word": {"wold"},
+	"wold": {"cold"},
+}
+
+func newTestController(t *testing.T, maxServers int, maxPlayersPerServer int) Controller {
+	key, err := utils.GenerateGCMKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewController(ctx, maxServers, maxPlayersPerServer, testDictionary, services.NewEncryption(key))
+}
+
+func TestLobby(t *testing.T) {
+	ctx := context.Background()
+	c := newTestController(t, 1, 2)
+
+	host, err := c.CreateMultiplayerLobby(ctx, "Host", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := host.Lobby.Code
+
+	_, err = c.CreateMultiplayerLobby(ctx, "Other", "other")
+	if !errors.Is(err, ErrTooManyLobbies) {
+		t.Errorf("expected ErrTooManyLobbies, got %v", err)
+	}
+	_, err = c.StartLobby(ctx, code, host.PlayerID)
+	if !errors.Is(err, ErrNotEnoughPlayers) {
+		t.Errorf("expected ErrNotEnoughPlayers, got %v", err)
+	}
+	_, err = c.JoinLobby(code, "host")
+	if !errors.Is(err, ErrNameTaken) {
+		t.Errorf("expected ErrNameTaken, got %v", err)
+	}
+
+	guest, err := c.JoinLobby(code, "Guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.JoinLobby(code, "Late")
+	if !errors.Is(err, ErrLobbyFull) {
+		t.Errorf("expected ErrLobbyFull, got %v", err)
+	}
+	_, err = c.StartLobby(ctx, code, guest.PlayerID)
+	if !errors.Is(err, ErrNotHost) {
+		t.Errorf("expected ErrNotHost, got %v", err)
+	}
+
+	lobby, err := c.StartLobby(ctx, code, host.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lobby.Status != PlayingStatus || len(lobby.Hints) != DefaultChainLength || lobby.Chain != nil {
+		t.Fatalf("expected a started lobby with its chain hidden, got %+v", lobby)
+	}
+
+	// The chain follows the dictionary, so every word after the first can
+	// be worked out from the one before it
+	word := lobby.Hints[0]
+	response, err := c.GuessLobby(code, guest.PlayerID, "wrong")
+	if err != nil || response.Correct {
+		t.Fatalf("expected an incorrect guess, got %+v, %v", response, err)
+	}
+	for range DefaultChainLength - 1 {
+		word = testDictionary[word][0]
+		response, err = c.GuessLobby(code, guest.PlayerID, word)
+		if err != nil || !response.Correct {
+			t.Fatalf("expected %s to be correct, got %+v, %v", word, response, err)
+		}
+	}
+
+	lobby = response.Lobby
+	if lobby.Status != FinishedStatus || lobby.Winner != "Guest" || len(lobby.Chain) != DefaultChainLength {
+		t.Fatalf("expected Guest to win, got %+v", lobby)
+	}
+	if lobby.Players[0].Progress != 1 || !lobby.Players[1].Finished {
+		t.Errorf("unexpected progress %+v", lobby.Players)
+	}
+	_, err = c.GuessLobby(code, host.PlayerID, testDictionary[lobby.Hints[0]][0])
+	if !errors.Is(err, ErrLobbyNotPlaying) {
+		t.Errorf("expected ErrLobbyNotPlaying, got %v", err)
+	}
+
+	// The host leaving passes the lobby on, and it closes once empty
+	lobby, err = c.LeaveLobby(code, host.PlayerID)
+	if err != nil || !lobby.Players[0].Host {
+		t.Fatalf("expected Guest to become the host, got %+v, %v", lobby, err)
+	}
+	_, err = c.LeaveLobby(code, guest.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetLobby(code)
+	if !errors.Is(err, ErrLobbyNotFound) {
+		t.Errorf("expected ErrLobbyNotFound, got %v", err)
+	}
+	_, err = c.CreateMultiplayerLobby(ctx, "Host", "host")
+	if err != nil {
+		t.Errorf("expected a lobby to be free, got %v", err)
+	}
+}
+
+func TestLobbyLimits(t *testing.T) {
+	ctx := context.Background()
+	c := newTestController(t, 5, 2)
+
+	// Each client can only have one lobby running
+	finished, err := c.CreateMultiplayerLobby(ctx, "Host", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.CreateMultiplayerLobby(ctx, "Host", "a")
+	if !errors.Is(err, ErrTooManyClientLobbies) {
+		t.Errorf("expected ErrTooManyClientLobbies, got %v", err)
+	}
+	playing, err := c.CreateMultiplayerLobby(ctx, "Host", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting, err := c.CreateMultiplayerLobby(ctx, "Host", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, host := range []LobbyMembership{finished, playing} {
+		guest, err := c.JoinLobby(host.Lobby.Code, "Guest")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lobby, err := c.StartLobby(ctx, host.Lobby.Code, host.PlayerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if host.Lobby.Code == finished.Lobby.Code {
+			word := lobby.Hints[0]
+			for range DefaultChainLength - 1 {
+				word = testDictionary[word][0]
+				_, err = c.GuessLobby(host.Lobby.Code, guest.PlayerID, word)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	// Lobbies that can't be played any more expire sooner than ones that
+	// are being played
+	pruneLobbies := c.(controller).pruneLobbies
+	exists := func(membership LobbyMembership) bool {
+		_, err := c.GetLobby(membership.Lobby.Code)
+		return err == nil
+	}
+	pruneLobbies(time.Now().Add(5 * time.Minute))
+	if exists(finished) || !exists(waiting) || !exists(playing) {
+		t.Error("expected only the finished lobby to expire")
+	}
+	pruneLobbies(time.Now().Add(15 * time.Minute))
+	if exists(waiting) || !exists(playing) {
+		t.Error("expected the waiting lobby to expire")
+	}
+	pruneLobbies(time.Now().Add(time.Hour))
+	if exists(playing) {
+		t.Error("expected the playing lobby to expire")
+	}
+
+	_, err = c.CreateMultiplayerLobby(ctx, "Host", "a")
+	if err != nil {
+		t.Errorf("expected the client to be able to create a lobby again, got %v", err)
+	}
+}
+
+func TestLobbyEvents(t *testing.T) {
+	ctx := context.Background()
+	c := newTestController(t, 1, 2)
+
+	host, err := c.CreateMultiplayerLobby(ctx, "Host", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := host.Lobby.Code
+	guest, err := c.JoinLobby(code, "Guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := c.LobbyEvents(ctx, code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.StartLobby(ctx, code, host.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LeaveLobby(code, guest.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.LeaveLobby(code, host.PlayerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Events recorded before following are sent too, and the lobby closing
+	// ends the events even though it has been removed
+	expected := []LobbyEventType{
+		PlayerJoinedEvent,
+		PlayerJoinedEvent,
+		GameStartedEvent,
+		PlayerLeftEvent,
+		PlayerLeftEvent,
+		LobbyClosedEvent,
+	}
+	i := 0
+	for event := range events {
+		if i >= len(expected) || event.Type != expected[i] || event.ID != i+1 {
+			t.Fatalf("unexpected event %d: %+v", i, event)
+		}
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("expected %d events, got %d", len(expected), i)
+	}
+
+	_, err = c.LobbyEvents(ctx, code, 0)
+	if !errors.Is(err, ErrLobbyNotFound) {
+		t.Errorf("expected ErrLobbyNotFound, got %v", err)
+	}
+}
+
+func TestLobbyEventsResume(t *testing.T) {
+	c := newTestController(t, 1, 2)
+
+	host, err := c.CreateMultiplayerLobby(context.Background(), "Host", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.JoinLobby(host.Lobby.Code, "Guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := c.LobbyEvents(ctx, host.Lobby.Code, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := <-events
+	if event.ID != 2 || event.Player != "Guest" || len(event.Lobby.Players) != 2 {
+		t.Errorf("expected to resume from Guest joining, got %+v", event)
+	}
+
+	// Cancelling stops following the lobby
+	cancel()
+	for range events {
+	}
+}
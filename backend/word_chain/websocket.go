@@ -0,0 +1,162 @@
+package wordchain
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// socketPingInterval is how often the server pings a WebSocket to
+	// check that it is still alive
+	socketPingInterval = 20 * time.Second
+	// socketPingTimeout is how long a WebSocket has to answer a ping
+	// before it is treated as disconnected
+	socketPingTimeout = 10 * time.Second
+	// socketWriteTimeout is the longest sending a single message may take
+	socketWriteTimeout = 10 * time.Second
+	// socketReadLimit is the largest message a player can send, in bytes
+	socketReadLimit = 4096
+)
+
+// LobbySocket plays a lobby over a WebSocket. The player must have
+// already joined the lobby, and is given by the playerId query parameter.
+// The lobby's events are sent after lastEventId, so that a player that
+// reconnects can carry on from where they were
+func (h handler) LobbySocket(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	playerID := r.URL.Query().Get("playerId")
+	lastEventID, ok := getLastEventID(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.controller.ConnectLobby(code, playerID)
+	if err != nil {
+		writeLobbyError(w, err)
+		return
+	}
+	// Players that drop out without leaving get the grace period to
+	// reconnect
+	defer h.controller.DisconnectLobby(code, playerID)
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: h.originPatterns,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(socketReadLimit)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	events, err := h.controller.LobbyEvents(ctx, code, lastEventID)
+	if err != nil {
+		conn.Close(websocket.StatusPolicyViolation, err.Error())
+		return
+	}
+
+	go sendLobbyEvents(ctx, cancel, conn, events)
+	go keepSocketAlive(ctx, cancel, conn)
+
+	for {
+		var request SocketRequest
+		err = wsjson.Read(ctx, conn, &request)
+		if err != nil {
+			return
+		}
+
+		switch request.Type {
+		case GuessMessage:
+			result, err := h.controller.GuessLobby(code, playerID, request.Guess)
+			if err != nil {
+				writeSocketError(ctx, conn, err)
+				continue
+			}
+			writeSocket(ctx, conn, SocketResponse{Type: GuessResultMessage, GuessResult: &result})
+		case StartMessage:
+			// Everyone is told the game has started through its event
+			_, err = h.controller.StartLobby(ctx, code, playerID)
+			if err != nil {
+				writeSocketError(ctx, conn, err)
+			}
+		case LeaveMessage:
+			_, err = h.controller.LeaveLobby(code, playerID)
+			if err != nil {
+				writeSocketError(ctx, conn, err)
+				continue
+			}
+			conn.Close(websocket.StatusNormalClosure, "Left the lobby")
+			return
+		case PingMessage:
+			writeSocket(ctx, conn, SocketResponse{Type: PongMessage})
+		default:
+			writeSocket(ctx, conn, SocketResponse{Type: ErrorMessage, Error: "Unknown message type"})
+		}
+	}
+}
+
+// sendLobbyEvents forwards a lobby's events to a WebSocket, closing it
+// once the lobby has closed
+func sendLobbyEvents(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	conn *websocket.Conn,
+	events <-chan LobbyEvent,
+) {
+	for event := range events {
+		err := writeSocket(ctx, conn, SocketResponse{Type: EventMessage, Event: &event})
+		if err != nil {
+			cancel()
+			return
+		}
+	}
+
+	// The events only stop before the context is done when the lobby closes
+	if ctx.Err() == nil {
+		conn.Close(websocket.StatusNormalClosure, "Lobby closed")
+		cancel()
+	}
+}
+
+// keepSocketAlive pings a WebSocket until the context is done, cancelling
+// it if a ping isn't answered in time
+func keepSocketAlive(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn) {
+	ticker := time.NewTicker(socketPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, socketPingTimeout)
+			err := conn.Ping(pingCtx)
+			pingCancel()
+			if err != nil {
+				cancel()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeSocket sends a single message to a WebSocket
+func writeSocket(ctx context.Context, conn *websocket.Conn, response SocketResponse) error {
+	ctx, cancel := context.WithTimeout(ctx, socketWriteTimeout)
+	defer cancel()
+
+	return wsjson.Write(ctx, conn, response)
+}
+
+// writeSocketError sends the message for an error from a lobby to a
+// WebSocket
+func writeSocketError(ctx context.Context, conn *websocket.Conn, err error) {
+	_, message := lobbyErrorResponse(err)
+	writeSocket(ctx, conn, SocketResponse{Type: ErrorMessage, Error: message})
+}
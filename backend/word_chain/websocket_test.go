@@ -0,0 +1,134 @@
+package wordchain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func TestLobbySocket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c := newTestController(t, 1, 2)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/word-chain/lobby/{code}/ws", NewHandler(c, nil).LobbySocket)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	host, err := c.CreateMultiplayerLobby(ctx, "Host", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	guest, err := c.JoinLobby(host.Lobby.Code, "Guest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func(playerID string, lastEventID string) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") +
+			"/word-chain/lobby/" + host.Lobby.Code + "/ws?playerId=" + playerID + "&lastEventId=" + lastEventID
+		conn, _, err := websocket.Dial(ctx, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+	// readUntil reads messages until one matches, failing on an error
+	readUntil := func(conn *websocket.Conn, match func(response SocketResponse) bool) SocketResponse {
+		for {
+			var response SocketResponse
+			err := wsjson.Read(ctx, conn, &response)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Type == ErrorMessage {
+				t.Fatalf("unexpected error %s", response.Error)
+			}
+			if match(response) {
+				return response
+			}
+		}
+	}
+	isEvent := func(eventType LobbyEventType, player string) func(response SocketResponse) bool {
+		return func(response SocketResponse) bool {
+			return response.Type == EventMessage && response.Event.Type == eventType && response.Event.Player == player
+		}
+	}
+
+	_, _, err = websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/word-chain/lobby/"+host.Lobby.Code+"/ws?playerId=nobody", nil)
+	if err == nil {
+		t.Error("expected an unknown player to be refused")
+	}
+
+	hostConn := dial(host.PlayerID, "")
+	defer hostConn.CloseNow()
+	guestConn := dial(guest.PlayerID, "")
+	readUntil(hostConn, isEvent(PlayerConnectedEvent, "Guest"))
+
+	// Dropping the connection keeps the player in the lobby, so they can
+	// reconnect and carry on from the last event they saw
+	guestConn.CloseNow()
+	disconnected := readUntil(hostConn, isEvent(PlayerDisconnectedEvent, "Guest"))
+	guestConn = dial(guest.PlayerID, "")
+	defer guestConn.CloseNow()
+	readUntil(hostConn, isEvent(PlayerConnectedEvent, "Guest"))
+	guestConn.CloseNow()
+	guestConn = dial(guest.PlayerID, strconv.Itoa(disconnected.Event.ID))
+	defer guestConn.CloseNow()
+	reconnected := readUntil(guestConn, func(response SocketResponse) bool { return response.Type == EventMessage })
+	if reconnected.Event.ID != disconnected.Event.ID+1 {
+		t.Errorf("expected to resume after event %d, got %d", disconnected.Event.ID, reconnected.Event.ID)
+	}
+
+	err = wsjson.Write(ctx, hostConn, SocketRequest{Type: StartMessage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := readUntil(guestConn, isEvent(GameStartedEvent, "Host"))
+
+	word := started.Event.Lobby.Hints[0]
+	for range DefaultChainLength - 1 {
+		word = testDictionary[word][0]
+		err = wsjson.Write(ctx, guestConn, SocketRequest{Type: GuessMessage, Guess: word})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := readUntil(guestConn, func(response SocketResponse) bool { return response.Type == GuessResultMessage })
+		if !result.GuessResult.Correct {
+			t.Fatalf("expected %s to be correct", word)
+		}
+	}
+	readUntil(hostConn, isEvent(GameFinishedEvent, "Guest"))
+
+	err = wsjson.Write(ctx, hostConn, SocketRequest{Type: PingMessage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readUntil(hostConn, func(response SocketResponse) bool { return response.Type == PongMessage })
+
+	// Once everyone has left, the lobby closes and so do the connections
+	for _, conn := range []*websocket.Conn{guestConn, hostConn} {
+		err = wsjson.Write(ctx, conn, SocketRequest{Type: LeaveMessage})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for err == nil {
+		_, _, err = hostConn.Read(ctx)
+	}
+	if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+		t.Errorf("expected the connection to close normally, got %v", err)
+	}
+	_, err = c.GetLobby(host.Lobby.Code)
+	if !errors.Is(err, ErrLobbyNotFound) {
+		t.Errorf("expected the lobby to close, got %v", err)
+	}
+}
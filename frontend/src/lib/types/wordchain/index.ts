@@ -7,7 +7,10 @@ export type LobbyCodeEvent = {
 };
 
 export type Chain = string[];
+export type WordChainDifficulty = "easy" | "medium" | "hard";
 export type WordChainState = {
+  length: number;
+  difficulty: WordChainDifficulty;
   generatedChain: Chain;
   userProgress: number;
 };